@@ -0,0 +1,134 @@
+package poker
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Evaluate finds the best 5 card hand out of 5 to 7 cards, e.g. hole cards + board
+func Evaluate(cards []int) (Hand, error) {
+	if len(cards) < 5 || len(cards) > 7 {
+		return Hand{}, ErrCardCount
+	}
+
+	seen := [52]bool{}
+	for _, card := range cards {
+		if card < 0 || card > 51 {
+			return Hand{}, ErrInvalidCard
+		}
+		if seen[card] {
+			return Hand{}, ErrDuplicateCard
+		}
+		seen[card] = true
+	}
+
+	// at most 21 combinations, not worth anything smarter
+	best := Hand{Category: -1}
+	n := len(cards)
+	for a := 0; a < n; a++ {
+		for b := a + 1; b < n; b++ {
+			for c := b + 1; c < n; c++ {
+				for d := c + 1; d < n; d++ {
+					for e := d + 1; e < n; e++ {
+						hand := evaluate5([5]int{cards[a], cards[b], cards[c], cards[d], cards[e]})
+						if hand.Compare(best) > 0 {
+							best = hand
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return best, nil
+}
+
+// Best returns the indexes of the strongest hands, more than one on a tie
+func Best(hands []Hand) []int {
+	winners := []int{}
+	for i, hand := range hands {
+		if len(winners) == 0 {
+			winners = append(winners, i)
+			continue
+		}
+
+		switch hand.Compare(hands[winners[0]]) {
+		case 1:
+			winners = append(winners[:0], i)
+		case 0:
+			winners = append(winners, i)
+		}
+	}
+	return winners
+}
+
+type rankGroup struct {
+	rank  int
+	count int
+}
+
+func evaluate5(cards [5]int) Hand {
+	// highest rank first so the card order reads naturally
+	slices.SortFunc(cards[:], func(a, b int) int { return cmp.Compare(RankOf(b), RankOf(a)) })
+
+	counts := [13]int{}
+	flush := true
+	for _, card := range cards {
+		counts[RankOf(card)]++
+		if SuitOf(card) != SuitOf(cards[0]) {
+			flush = false
+		}
+	}
+
+	// pairs, trips and quads first, bigger groups before higher ranks
+	groups := []rankGroup{}
+	for r := RankAce; r >= RankTwo; r-- {
+		if counts[r] > 0 {
+			groups = append(groups, rankGroup{rank: r, count: counts[r]})
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b rankGroup) int { return cmp.Compare(b.count, a.count) })
+
+	hand := Hand{Cards: cards, Ranks: [5]int{-1, -1, -1, -1, -1}}
+	for i, g := range groups {
+		hand.Ranks[i] = g.rank
+	}
+
+	straightHigh, straight := -1, false
+	if len(groups) == 5 {
+		high, low := RankOf(cards[0]), RankOf(cards[4])
+		if high-low == 4 {
+			straightHigh, straight = high, true
+		} else if high == RankAce && RankOf(cards[1]) == RankFive {
+			// the wheel, A-2-3-4-5 counts as 5 high
+			straightHigh, straight = RankFive, true
+		}
+	}
+
+	switch {
+	case straight && flush:
+		hand.Category = StraightFlush
+	case groups[0].count == 4:
+		hand.Category = FourOfAKind
+	case groups[0].count == 3 && groups[1].count == 2:
+		hand.Category = FullHouse
+	case flush:
+		hand.Category = Flush
+	case straight:
+		hand.Category = Straight
+	case groups[0].count == 3:
+		hand.Category = ThreeOfAKind
+	case groups[0].count == 2 && groups[1].count == 2:
+		hand.Category = TwoPair
+	case groups[0].count == 2:
+		hand.Category = OnePair
+	default:
+		hand.Category = HighCard
+	}
+
+	if straight {
+		hand.Ranks = [5]int{straightHigh, -1, -1, -1, -1}
+	}
+
+	return hand
+}
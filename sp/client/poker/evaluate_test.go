@@ -0,0 +1,154 @@
+package poker
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// cards turns "Ah Td 2c" into card ids, suits in the order hearts, diamonds, clubs, spades
+func cards(t *testing.T, hand string) []int {
+	t.Helper()
+
+	ids := []int{}
+	for _, name := range strings.Fields(hand) {
+		rank := strings.IndexByte("23456789TJQKA", name[0])
+		suit := strings.IndexByte("hdcs", name[1])
+		if len(name) != 2 || rank < 0 || suit < 0 {
+			t.Fatalf("bad card %q", name)
+		}
+		ids = append(ids, suit*13+rank)
+	}
+	return ids
+}
+
+func evaluate(t *testing.T, hand string) Hand {
+	t.Helper()
+
+	h, err := Evaluate(cards(t, hand))
+	if err != nil {
+		t.Fatalf("%s: %v", hand, err)
+	}
+	return h
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		cards    string
+		category Category
+		describe string
+	}{
+		{"Ah Kd 9c 7s 4h 3d 2c", HighCard, "Ace High"},
+		{"Kh Kd 9c 7s 4h 3d 2c", OnePair, "Pair of Kings"},
+		{"Kh Kd 7c 7s 4h 3d 2c", TwoPair, "Two Pair, Kings and Sevens"},
+		{"7h 7d 7c Ks 4h 3d 2c", ThreeOfAKind, "Three of a Kind, Sevens"},
+		{"Ah 2d 3c 4s 5h Kd Qc", Straight, "Straight, Five High"},
+		{"Th Jd Qc Ks Ah 2d 3c", Straight, "Straight, Ace High"},
+		{"2h 7h 9h Jh Kh Ad Ac", Flush, "Flush, King High"},
+		{"Kh Kd Kc 7s 7h 3d 2c", FullHouse, "Full House, Kings over Sevens"},
+		{"9h 9d 9c 9s Ah 3d 2c", FourOfAKind, "Four of a Kind, Nines"},
+		{"Ah 2h 3h 4h 5h Kd Qc", StraightFlush, "Straight Flush, Five High"},
+		{"9s Ts Js Qs Ks Ah Ad", StraightFlush, "Straight Flush, King High"},
+		{"Ts Js Qs Ks As", StraightFlush, "Royal Flush"},
+
+		// two pair out of three takes the highest two
+		{"Kh Kd 7c 7s 4h 4d Ac", TwoPair, "Two Pair, Kings and Sevens"},
+		// a flush beats the straight in the same cards
+		{"5h 6h 7h 8h Jh 9d 2c", Flush, "Flush, Jack High"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cards, func(t *testing.T) {
+			hand := evaluate(t, tt.cards)
+			if hand.Category != tt.category {
+				t.Errorf("category %s, want %s", hand.Category, tt.category)
+			}
+			if got := hand.Describe(); got != tt.describe {
+				t.Errorf("Describe() = %q, want %q", got, tt.describe)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{"kicker decides", "Kh Kd Ac 7s 4h", "Ks Kc Qd 7h 4d", 1},
+		{"last kicker decides", "Kh Kd Ac 7s 5h", "Ks Kc Ad 7h 4d", 1},
+		{"same ranks tie", "Kh Kd Ac 7s 4h", "Ks Kc Ad 7h 4d", 0},
+		{"only the best five count", "Kh Kd Ac Qs Jh 3d 2c", "Ks Kc Ad Qh Jd 4h 3s", 0},
+		{"second pair decides", "Kh Kd 8c 8s 2h", "Ks Kc 7d 7h Ad", 1},
+		{"wheel is the lowest straight", "Ah 2d 3c 4s 5h", "2h 3d 4c 5s 6h", -1},
+		{"steel wheel beats quads", "Ah 2h 3h 4h 5h", "Ks Kc Kd Kh 2s", 1},
+		{"category beats ranks", "2h 2d 3c 3s 4h", "Ah Kd Qc Js 9h", 1},
+		{"full house trips first", "3h 3d 3c 2s 2h", "2c 2d 2s As Ah", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := evaluate(t, tt.a), evaluate(t, tt.b)
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("%s vs %s: Compare = %d, want %d", a, b, got, tt.want)
+			}
+			if got := b.Compare(a); got != -tt.want {
+				t.Errorf("%s vs %s: reverse Compare = %d, want %d", b, a, got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestBest(t *testing.T) {
+	board := "2h 7d 9c Js Kh"
+	tests := []struct {
+		name  string
+		holes []string
+		want  []int
+	}{
+		{"single winner", []string{"Qc 4s", "Ah Ad", "Qd 4c"}, []int{1}},
+		{"tie between two", []string{"Qc 4s", "Qd 4c", "3s 4d"}, []int{0, 1}},
+		{"everyone plays the board", []string{"3c 4s", "3d 4c"}, []int{0, 1}},
+		{"winner after a tie", []string{"Qc 4s", "Qd 4c", "Ac Kd"}, []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hands := []Hand{}
+			for _, hole := range tt.holes {
+				hands = append(hands, evaluate(t, hole+" "+board))
+			}
+			if got := Best(hands); !slices.Equal(got, tt.want) {
+				t.Errorf("Best = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Best(nil); len(got) != 0 {
+		t.Errorf("Best(nil) = %v", got)
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cards []int
+		want  error
+	}{
+		{"too few", []int{0, 1, 2, 3}, ErrCardCount},
+		{"too many", []int{0, 1, 2, 3, 4, 5, 6, 7}, ErrCardCount},
+		{"over 51", []int{0, 1, 2, 3, 52}, ErrInvalidCard},
+		{"negative", []int{-1, 1, 2, 3, 4}, ErrInvalidCard},
+		{"duplicate", []int{0, 1, 2, 3, 3}, ErrDuplicateCard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Evaluate(tt.cards); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
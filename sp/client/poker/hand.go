@@ -0,0 +1,111 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Card ids are 0-51 like on the wire, suit = id / 13 and rank = id % 13 (2 .. Ace)
+const (
+	RankTwo   = 0
+	RankFive  = 3
+	RankTen   = 8
+	RankJack  = 9
+	RankQueen = 10
+	RankKing  = 11
+	RankAce   = 12
+)
+
+var (
+	ErrCardCount     = errors.New("poker: need 5 to 7 cards")
+	ErrInvalidCard   = errors.New("poker: card id out of range")
+	ErrDuplicateCard = errors.New("poker: duplicate card")
+)
+
+type Category int
+
+const (
+	HighCard Category = iota
+	OnePair
+	TwoPair
+	ThreeOfAKind
+	Straight
+	Flush
+	FullHouse
+	FourOfAKind
+	StraightFlush
+)
+
+var categoryNames = []string{
+	"High Card", "Pair", "Two Pair", "Three of a Kind", "Straight",
+	"Flush", "Full House", "Four of a Kind", "Straight Flush",
+}
+
+func (c Category) String() string {
+	if c < HighCard || c > StraightFlush {
+		return "Unknown"
+	}
+	return categoryNames[c]
+}
+
+var rankNames = []string{"Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten", "Jack", "Queen", "King", "Ace"}
+var rankPlurals = []string{"Twos", "Threes", "Fours", "Fives", "Sixes", "Sevens", "Eights", "Nines", "Tens", "Jacks", "Queens", "Kings", "Aces"}
+
+func RankOf(card int) int { return card % 13 }
+func SuitOf(card int) int { return card / 13 }
+
+// Hand is the best 5 cards out of the ones evaluated
+type Hand struct {
+	Category Category
+
+	// ranks in the order they matter for ties, unused slots are -1
+	Ranks [5]int
+
+	// the 5 card ids making up the hand
+	Cards [5]int
+}
+
+// Compare returns -1, 0 or 1 like cmp.Compare
+func (h Hand) Compare(o Hand) int {
+	if h.Category != o.Category {
+		if h.Category < o.Category {
+			return -1
+		}
+		return 1
+	}
+	return slices.Compare(h.Ranks[:], o.Ranks[:])
+}
+
+// Describe gives the name players know, e.g. "Two Pair, Kings and Sevens"
+func (h Hand) Describe() string {
+	r := h.Ranks
+	switch h.Category {
+	case HighCard:
+		return rankNames[r[0]] + " High"
+	case OnePair:
+		return "Pair of " + rankPlurals[r[0]]
+	case TwoPair:
+		return fmt.Sprintf("Two Pair, %s and %s", rankPlurals[r[0]], rankPlurals[r[1]])
+	case ThreeOfAKind:
+		return "Three of a Kind, " + rankPlurals[r[0]]
+	case Straight:
+		return fmt.Sprintf("Straight, %s High", rankNames[r[0]])
+	case Flush:
+		return fmt.Sprintf("Flush, %s High", rankNames[r[0]])
+	case FullHouse:
+		return fmt.Sprintf("Full House, %s over %s", rankPlurals[r[0]], rankPlurals[r[1]])
+	case FourOfAKind:
+		return "Four of a Kind, " + rankPlurals[r[0]]
+	case StraightFlush:
+		if r[0] == RankAce {
+			return "Royal Flush"
+		}
+		return fmt.Sprintf("Straight Flush, %s High", rankNames[r[0]])
+	}
+	return h.Category.String()
+}
+
+func (h Hand) String() string {
+	return h.Describe()
+}
@@ -0,0 +1,40 @@
+package main
+
+import "math/rand"
+
+// Card ids are 0-51, suit = id / 13 and rank = id % 13 (2 .. Ace)
+type Deck struct {
+	cards []int
+	rng   *rand.Rand
+}
+
+func NewDeck() Deck {
+	d := Deck{rng: rand.New(rand.NewSource(rand.Int63()))}
+	d.Reset()
+	return d
+}
+
+func (d *Deck) Reset() {
+	d.cards = d.cards[:0]
+	for id := range 52 {
+		d.cards = append(d.cards, id)
+	}
+	d.Shuffle()
+}
+
+func (d *Deck) Shuffle() {
+	d.rng.Shuffle(len(d.cards), func(i, j int) {
+		d.cards[i], d.cards[j] = d.cards[j], d.cards[i]
+	})
+}
+
+// Draw takes the top card, a full hand never gets close to emptying the deck
+func (d *Deck) Draw() int {
+	if len(d.cards) == 0 {
+		d.Reset()
+	}
+
+	card := d.cards[len(d.cards)-1]
+	d.cards = d.cards[:len(d.cards)-1]
+	return card
+}
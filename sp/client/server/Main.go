@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+func main() {
+	ip := flag.String("ip", "", "address to listen on, all interfaces if empty")
+	port := flag.String("port", "8080", "port to listen on")
+	maxPlayers := flag.Int("players", 16, "maximum players outside of rooms")
+	rooms := flag.Int("rooms", 4, "number of rooms")
+	seats := flag.Int("seats", 4, "seats per room")
+	debug := flag.Bool("debug", false, "use the long debug timeouts")
+	flag.Parse()
+
+	cfg := Config{
+		MaxPlayers:  *maxPlayers,
+		RoomCount:   *rooms,
+		RoomSeats:   *seats,
+		TurnTimeout: 30 * time.Second,
+		SDTimeout:   15 * time.Second,
+		PingTimeout: 10 * time.Second,
+	}
+
+	// same values as the DEBUG build of the C++ server
+	if *debug {
+		cfg.TurnTimeout = 60 * time.Second
+		cfg.SDTimeout = 30 * time.Second
+		cfg.PingTimeout = 60 * time.Second
+	}
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		fmt.Println("Failed to create server:", err)
+		os.Exit(1)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(*ip, *port))
+	if err != nil {
+		fmt.Println("Failed to open socket:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Listening on", listener.Addr())
+	if err := server.Run(listener); err != nil {
+		fmt.Println("Server stopped:", err)
+		os.Exit(1)
+	}
+}
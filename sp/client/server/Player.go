@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	unet "poker-client/ups_net"
+)
+
+const inboxSize = 128
+
+type PlayerState int
+
+const (
+	PlayerConnected         PlayerState = iota // Just connected, waiting for CONN
+	PlayerAwaitingReconnect                    // Sent RCON, waiting for RCON or PINF
+	PlayerAwaitingInfo                         // Sent PNOK, waiting for PINF
+	PlayerSendingRooms                         // Sending the room list
+	PlayerAwaitingJoin                         // Room list done, waiting for JOIN
+	PlayerInRoom                               // Owned by a room
+	PlayerSpectating                           // Owned by a room, without a seat
+)
+
+// Player is one client connection. The reader goroutine answers keepalives
+// on its own, everything else is queued for whoever owns the player.
+type Player struct {
+	conn     net.Conn
+	writer   *unet.FrameWriter
+	writeMtx sync.Mutex
+	inbox    chan unet.NetMsg
+
+	disconnected atomic.Bool
+	pingReceived atomic.Bool
+
+	Nickname     string
+	Chips        int
+	State        PlayerState // only the lobby sets it, ReturnPlayer under playerMtx
+	Capabilities []string
+
+	roomSendIndex int
+	reconnectRoom int
+
+	chat unet.ChatLimiter
+}
+
+func NewPlayer(conn net.Conn) *Player {
+	p := &Player{
+		conn:   conn,
+		writer: unet.NewFrameWriter(conn),
+		inbox:  make(chan unet.NetMsg, inboxSize),
+		State:  PlayerConnected,
+	}
+	p.pingReceived.Store(true)
+
+	go p.readLoop()
+	return p
+}
+
+func (p *Player) readLoop() {
+	reader := unet.NewFrameReader(p.conn)
+
+	for !p.disconnected.Load() {
+		msg, err := reader.ReadFrame()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				fmt.Printf("Client %s disconnected\n", p.conn.RemoteAddr())
+			} else if !p.disconnected.Load() {
+				fmt.Printf("Read error from %s: %v\n", p.conn.RemoteAddr(), err)
+			}
+			p.Disconnect()
+			return
+		}
+
+		switch msg.Code {
+		case unet.CodeAliveRequest:
+			p.Send(&unet.AliveReplyMsg{})
+		case unet.CodePing:
+			p.pingReceived.Store(true)
+		default:
+			select {
+			case p.inbox <- msg:
+			default:
+				fmt.Printf("Inbox of %s full, disconnecting\n", p.Nickname)
+				p.Disconnect()
+			}
+		}
+	}
+}
+
+// Read returns the next queued message without blocking
+func (p *Player) Read() (unet.NetMsg, bool) {
+	select {
+	case msg := <-p.inbox:
+		return msg, true
+	default:
+		return unet.NetMsg{}, false
+	}
+}
+
+func (p *Player) Send(m unet.Message) {
+	msg, err := unet.EncodeMsg(m)
+	if err != nil {
+		fmt.Println("Failed to encode message:", err)
+		return
+	}
+
+	p.SendNetMsg(msg)
+}
+
+func (p *Player) SendNetMsg(msg unet.NetMsg) {
+	if p.disconnected.Load() {
+		return
+	}
+
+	fmt.Printf("Sending -> %s | %s: %q\n", p.Nickname, msg.Code, msg.Payload)
+
+	p.writeMtx.Lock()
+	err := p.writer.WriteFrame(msg)
+	p.writeMtx.Unlock()
+
+	if err != nil {
+		fmt.Printf("Send error to %s, disconnecting: %v\n", p.Nickname, err)
+		p.Disconnect()
+	}
+}
+
+func (p *Player) ClearPing() {
+	p.pingReceived.Store(false)
+}
+
+func (p *Player) GotPing() bool {
+	return p.pingReceived.Load()
+}
+
+func (p *Player) SendPing() {
+	p.Send(&unet.PingMsg{})
+}
+
+func (p *Player) IsConnected() bool {
+	return !p.disconnected.Load()
+}
+
+func (p *Player) Disconnect() {
+	if p.disconnected.Swap(true) {
+		return
+	}
+	p.conn.Close()
+}
+
+func (p *Player) HasCapability(capability string) bool {
+	return slices.Contains(p.Capabilities, capability)
+}
+
+// checkChat answers CHFL to what can't be said, the caller broadcasts the rest
+func (p *Player) checkChat(chat *unet.ChatMsg) bool {
+	if !p.HasCapability(unet.CapChat) || chat.Text == "" || !unet.ChatFits(p.Nickname, chat.Text) {
+		fmt.Printf("Rejected chat message from %s (%d bytes)\n", p.Nickname, len(chat.Text))
+		p.Send(&unet.ChatFailMsg{})
+		return false
+	}
+
+	if !p.chat.Allow(time.Now()) {
+		fmt.Printf("%s is chatting too fast\n", p.Nickname)
+		p.Send(&unet.ChatFailMsg{})
+		return false
+	}
+
+	return true
+}
+
+// negotiate keeps what both sides support and turns on what needs turning on
+func (p *Player) negotiate(conn *unet.ConnMsg) (int, []string) {
+	if conn.Version == unet.LegacyVersion {
+		return unet.LegacyVersion, nil
+	}
+
+	version := min(conn.Version, unet.ProtocolVersion)
+	p.Capabilities = nil
+	for _, capability := range conn.Capabilities {
+		if slices.Contains(serverCapabilities, capability) {
+			p.Capabilities = append(p.Capabilities, capability)
+		}
+	}
+
+	p.writeMtx.Lock()
+	p.writer.SetExtended(p.HasCapability(unet.CapExtendedFrames))
+	p.writeMtx.Unlock()
+
+	return version, p.Capabilities
+}
@@ -0,0 +1,726 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"poker-client/poker"
+	unet "poker-client/ups_net"
+)
+
+type RoundPhase int
+
+const (
+	PhasePreFlop RoundPhase = iota
+	PhaseFlop
+	PhaseTurn
+	PhaseRiver
+)
+
+// Seat is the persistent data of a player slot, it outlives the connection
+type Seat struct {
+	Occupied bool
+
+	Nickname string
+	Chips    int
+	RoundBet int
+	TotalBet int
+
+	IsFolded     bool
+	IsReady      bool
+	ShowdownOK   bool
+	CardsDealt   bool
+	Hand         [2]int
+	Action       int
+	ActionAmount int
+
+	conn *Player
+}
+
+func (s *Seat) resetGame() {
+	s.IsFolded = false
+	s.IsReady = false
+	s.ShowdownOK = false
+	s.CardsDealt = false
+	s.Hand = [2]int{}
+	s.RoundBet = 0
+	s.TotalBet = 0
+	s.Action = unet.ActionNone
+	s.ActionAmount = 0
+}
+
+func (s *Seat) IsActive() bool {
+	return s.Occupied && s.conn != nil && s.conn.IsConnected()
+}
+
+// RoomState is one phase of the room DFA, same shape as the client's LogicState
+type RoomState interface {
+	Enter(r *Room)
+	Tick(r *Room)
+	Leave(r *Room)
+	Message(r *Room, seatIdx int, msg unet.Message)
+	Name() string
+}
+
+type Room struct {
+	ID   int
+	Name string
+
+	server *Server
+	cfg    Config
+
+	// held for a whole tick, the lobby thread only takes it for the room summary
+	mtx sync.Mutex
+
+	incomingMtx sync.Mutex
+	incoming    []*Player
+
+	// get every broadcast, nothing in the game waits for them
+	spectators []*Player
+
+	seats     []Seat
+	deck      Deck
+	community []int
+	pot       int
+	highBet   int
+	dealer    int
+	actor     int
+	locked    bool
+	phase     RoundPhase
+
+	state     RoomState
+	nextState RoomState
+	lastPing  time.Time
+}
+
+func NewRoom(id int, name string, server *Server, cfg Config) *Room {
+	return &Room{
+		ID:       id,
+		Name:     name,
+		server:   server,
+		cfg:      cfg,
+		seats:    make([]Seat, cfg.RoomSeats),
+		deck:     NewDeck(),
+		actor:    -1,
+		state:    &LobbyState{},
+		lastPing: time.Now(),
+	}
+}
+
+func (r *Room) TransitionTo(state RoomState) {
+	r.nextState = state
+}
+
+// AcceptPlayer queues a player, the room seats them on its next tick
+func (r *Room) AcceptPlayer(p *Player) {
+	r.incomingMtx.Lock()
+	r.incoming = append(r.incoming, p)
+	r.incomingMtx.Unlock()
+}
+
+func (r *Room) Info() *unet.RoomMsg {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return &unet.RoomMsg{
+		ID:             r.ID,
+		Name:           r.Name,
+		CurrentPlayers: r.countOccupied(),
+		MaxPlayers:     len(r.seats),
+	}
+}
+
+// CanJoin only lets players back into a running game if they already had a seat
+func (r *Room) CanJoin(nickname string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.locked {
+		for _, seat := range r.seats {
+			if seat.Occupied && seat.Nickname == nickname {
+				return true
+			}
+		}
+		return false
+	}
+
+	return r.countOccupied() < len(r.seats)
+}
+
+func (r *Room) HasDisconnectedSeat(nickname string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for _, seat := range r.seats {
+		if seat.Occupied && seat.Nickname == nickname && seat.conn == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Room) Run() {
+	r.mtx.Lock()
+	r.state.Enter(r)
+	r.mtx.Unlock()
+
+	for {
+		r.mtx.Lock()
+
+		if time.Since(r.lastPing) > r.cfg.PingTimeout {
+			r.lastPing = time.Now()
+			r.pingSeats()
+		}
+
+		r.processIncoming()
+		r.processNetwork()
+		r.processSpectators()
+		r.state.Tick(r)
+
+		if r.nextState != nil {
+			r.state.Leave(r)
+			r.state = r.nextState
+			r.nextState = nil
+			r.state.Enter(r)
+		}
+
+		r.mtx.Unlock()
+		time.Sleep(tickInterval)
+	}
+}
+
+func (r *Room) pingSeats() {
+	for i := range r.seats {
+		seat := &r.seats[i]
+		if seat.conn == nil {
+			continue
+		}
+
+		if !seat.conn.IsConnected() {
+			seat.conn = nil
+			r.playerLeave(i)
+			continue
+		}
+
+		if !seat.conn.GotPing() {
+			fmt.Printf("Player %s didn't send ping\n", seat.Nickname)
+			seat.conn.Disconnect()
+			seat.conn = nil
+			r.playerLeave(i)
+			continue
+		}
+
+		seat.conn.ClearPing()
+		seat.conn.SendPing()
+	}
+
+	for _, p := range r.spectators {
+		if !p.GotPing() {
+			fmt.Printf("Spectator %s didn't send ping\n", p.Nickname)
+			p.Disconnect()
+			continue
+		}
+
+		p.ClearPing()
+		p.SendPing()
+	}
+}
+
+func (r *Room) processIncoming() {
+	r.incomingMtx.Lock()
+	incoming := r.incoming
+	r.incoming = nil
+	r.incomingMtx.Unlock()
+
+	for _, p := range incoming {
+		if p.State == PlayerSpectating {
+			r.spectators = append(r.spectators, p)
+			p.Send(r.snapshot(-1))
+			continue
+		}
+
+		if idx, ok := r.seatPlayer(p); ok {
+			p.Send(r.snapshot(idx))
+			r.broadcastEx(idx, &unet.PlayerJoinedMsg{Player: r.playerSnapshot(idx)})
+			continue
+		}
+
+		fmt.Printf("No seat for %s, returning to main list\n", p.Nickname)
+		r.server.ReturnPlayer(p)
+	}
+}
+
+// seatPlayer prefers the seat the player lost on disconnect over a free one
+func (r *Room) seatPlayer(p *Player) (int, bool) {
+	for i := range r.seats {
+		seat := &r.seats[i]
+		if seat.Occupied && seat.Nickname == p.Nickname && seat.conn == nil {
+			fmt.Printf("Reconnecting %s to seat %d\n", p.Nickname, i)
+			seat.conn = p
+			return i, true
+		}
+	}
+
+	if r.locked {
+		return -1, false
+	}
+
+	for i := range r.seats {
+		seat := &r.seats[i]
+		if seat.Occupied {
+			continue
+		}
+
+		*seat = Seat{
+			Occupied: true,
+			Nickname: p.Nickname,
+			Chips:    p.Chips,
+			conn:     p,
+		}
+
+		fmt.Printf("New player %s at seat %d | %d\n", p.Nickname, i, p.Chips)
+		return i, true
+	}
+
+	return -1, false
+}
+
+func (r *Room) processNetwork() {
+	for i := range r.seats {
+		seat := &r.seats[i]
+		if !seat.Occupied || seat.conn == nil {
+			continue
+		}
+
+		if !seat.conn.IsConnected() {
+			fmt.Printf("Player %s disconnected (seat %d)\n", seat.Nickname, i)
+			seat.conn = nil
+			continue
+		}
+
+		for seat.conn != nil {
+			raw, ok := seat.conn.Read()
+			if !ok {
+				break
+			}
+
+			fmt.Printf("Processing %s for %s\n", raw.Code, seat.Nickname)
+
+			msg, err := unet.DecodeMsg(raw)
+			if err != nil || !isRoomMessage(msg) {
+				fmt.Printf("Bad room message %s from %s, disconnecting: %v\n", raw.Code, seat.Nickname, err)
+				seat.conn.Disconnect()
+				break
+			}
+
+			if _, ok := msg.(*unet.LeaveMsg); ok {
+				fmt.Printf("Player %s leaving room\n", seat.Nickname)
+				r.playerLeave(i)
+				break
+			}
+
+			if chat, ok := msg.(*unet.ChatMsg); ok {
+				r.chat(seat.conn, chat)
+				continue
+			}
+
+			// the client couldn't apply the update, give it the whole state the old way
+			if _, ok := msg.(*unet.UpdateFailMsg); ok {
+				fmt.Printf("Player %s failed a room update, resending state\n", seat.Nickname)
+				r.sendTo(i, r.snapshot(i))
+				continue
+			}
+
+			r.state.Message(r, i, msg)
+		}
+	}
+}
+
+// processSpectators only has to handle leaving, their acks don't matter
+func (r *Room) processSpectators() {
+	spectators := r.spectators[:0]
+	for _, p := range r.spectators {
+		if r.spectatorMessages(p) {
+			spectators = append(spectators, p)
+		}
+	}
+
+	clear(r.spectators[len(spectators):])
+	r.spectators = spectators
+}
+
+// spectatorMessages returns false once the spectator is gone
+func (r *Room) spectatorMessages(p *Player) bool {
+	for p.IsConnected() {
+		raw, ok := p.Read()
+		if !ok {
+			return true
+		}
+
+		msg, err := unet.DecodeMsg(raw)
+		if err != nil || !isRoomMessage(msg) {
+			fmt.Printf("Bad room message %s from spectator %s, disconnecting: %v\n", raw.Code, p.Nickname, err)
+			p.Disconnect()
+			break
+		}
+
+		switch m := msg.(type) {
+		case *unet.LeaveMsg:
+			fmt.Printf("Spectator %s leaving room\n", p.Nickname)
+			r.server.ReturnPlayer(p)
+			return false
+
+		case *unet.UpdateFailMsg:
+			p.Send(r.snapshot(-1))
+
+		case *unet.ChatMsg:
+			r.chat(p, m)
+		}
+	}
+
+	fmt.Printf("Spectator %s disconnected\n", p.Nickname)
+	return false
+}
+
+func isRoomMessage(msg unet.Message) bool {
+	switch msg.(type) {
+	case *unet.ReadyMsg, *unet.LeaveMsg, *unet.ChatMsg,
+		*unet.CheckMsg, *unet.FoldMsg, *unet.CallMsg, *unet.BetMsg,
+		*unet.CardsOKMsg, *unet.CardsFailMsg,
+		*unet.StateOKMsg, *unet.StateFailMsg,
+		*unet.UpdateOKMsg, *unet.UpdateFailMsg,
+		*unet.DoneOKMsg, *unet.DoneFailMsg,
+		*unet.ShowdownOKMsg, *unet.ShowdownFailMsg,
+		*unet.WinOKMsg, *unet.WinFailMsg:
+		return true
+	}
+	return false
+}
+
+// playerLeave hands the connection back to the server. Outside the lobby the
+// seat stays taken so the hand can finish and the player can come back
+func (r *Room) playerLeave(seatIdx int) {
+	seat := &r.seats[seatIdx]
+	seat.Action = unet.ActionLeft
+
+	if seat.conn != nil {
+		r.server.ReturnPlayer(seat.conn)
+		fmt.Println("Player moved back to main list")
+	}
+
+	seat.conn = nil
+	if r.state.Name() == "Lobby" {
+		seat.Occupied = false
+		seat.IsReady = false
+		seat.Nickname = ""
+	}
+
+	r.broadcastEx(seatIdx, r.actionMsg(seatIdx))
+}
+
+func (r *Room) countOccupied() int {
+	count := 0
+	for _, seat := range r.seats {
+		if seat.Occupied {
+			count++
+		}
+	}
+	return count
+}
+
+// countInHand counts players that can still win the pot, disconnected ones included
+func (r *Room) countInHand() int {
+	count := 0
+	for _, seat := range r.seats {
+		if seat.Occupied && seat.CardsDealt && !seat.IsFolded {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Room) nextOccupied(idx int) int {
+	for i := 1; i <= len(r.seats); i++ {
+		next := (idx + i) % len(r.seats)
+		if r.seats[next].Occupied {
+			return next
+		}
+	}
+	return idx
+}
+
+// countCanBet counts players in the hand that still have chips, all-in ones have nothing left to decide
+func (r *Room) countCanBet() int {
+	count := 0
+	for _, seat := range r.seats {
+		if seat.Occupied && seat.CardsDealt && !seat.IsFolded && seat.Chips > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// seatsAfter lists the seats that still have to act, in turn order starting after idx
+func (r *Room) seatsAfter(idx int, includeIdx bool) []int {
+	order := []int{}
+	for i := 1; i <= len(r.seats); i++ {
+		next := (idx + i) % len(r.seats)
+		if next == idx && !includeIdx {
+			continue
+		}
+
+		seat := &r.seats[next]
+		if seat.IsActive() && seat.CardsDealt && !seat.IsFolded && seat.Chips > 0 {
+			order = append(order, next)
+		}
+	}
+	return order
+}
+
+// pot is the main pot or a side pot, only the seats that matched its level can win it
+type pot struct {
+	amount   int
+	eligible []int // in turn order after the dealer
+}
+
+// pots splits everything bet this hand at every all-in level. Players still in
+// the hand that aren't all-in all bet the same, so their level is the top one.
+func (r *Room) pots() []pot {
+	contenders := []int{}
+	levels := []int{}
+	for i := 1; i <= len(r.seats); i++ {
+		idx := (r.dealer + i) % len(r.seats)
+		seat := &r.seats[idx]
+		if seat.Occupied && seat.CardsDealt && !seat.IsFolded {
+			contenders = append(contenders, idx)
+			if !slices.Contains(levels, seat.TotalBet) {
+				levels = append(levels, seat.TotalBet)
+			}
+		}
+	}
+	slices.Sort(levels)
+
+	pots := []pot{}
+	counted, prev := 0, 0
+	for _, level := range levels {
+		p := pot{}
+		for i := range r.seats {
+			p.amount += max(0, min(r.seats[i].TotalBet, level)-prev)
+		}
+		for _, idx := range contenders {
+			if r.seats[idx].TotalBet >= level {
+				p.eligible = append(p.eligible, idx)
+			}
+		}
+
+		if p.amount > 0 || len(pots) == 0 {
+			pots = append(pots, p)
+		}
+		counted += p.amount
+		prev = level
+	}
+
+	// whatever folded players put in over the top level goes with the last pot
+	if len(pots) > 0 && r.pot > counted {
+		pots[len(pots)-1].amount += r.pot - counted
+	}
+
+	return pots
+}
+
+// payouts splits every pot between its best hands, the odd chips go to the
+// first winner after the dealer. Empty if everybody folded.
+func (r *Room) payouts() map[int]int {
+	paid := map[int]int{}
+	hands := map[int]poker.Hand{}
+
+	for _, p := range r.pots() {
+		// last one standing doesn't have to show anything
+		winners := p.eligible
+		if len(p.eligible) > 1 {
+			shown := []int{}
+			scores := []poker.Hand{}
+			for _, idx := range p.eligible {
+				score, ok := hands[idx]
+				if !ok {
+					var err error
+					score, err = poker.Evaluate(append(r.seats[idx].Hand[:], r.community...))
+					if err != nil {
+						fmt.Println("Failed to score hand of", r.seats[idx].Nickname, err)
+						continue
+					}
+					hands[idx] = score
+				}
+
+				shown = append(shown, idx)
+				scores = append(scores, score)
+			}
+
+			winners = []int{}
+			for _, i := range poker.Best(scores) {
+				winners = append(winners, shown[i])
+			}
+		}
+
+		if len(winners) == 0 {
+			continue
+		}
+
+		share := p.amount / len(winners)
+		for _, idx := range winners {
+			paid[idx] += share
+		}
+		paid[winners[0]] += p.amount % len(winners)
+	}
+
+	return paid
+}
+
+func (r *Room) countActive() int {
+	count := 0
+	for i := range r.seats {
+		if r.seats[i].IsActive() {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Room) broadcast(m unet.Message) {
+	r.broadcastEx(-1, m)
+}
+
+func (r *Room) broadcastEx(seatIdx int, m unet.Message) {
+	msg, err := unet.EncodeMsg(m)
+	if err != nil {
+		fmt.Println("Failed to encode broadcast:", err)
+		return
+	}
+
+	for i := range r.seats {
+		if i != seatIdx && r.seats[i].IsActive() {
+			r.seats[i].conn.SendNetMsg(msg)
+		}
+	}
+
+	for _, p := range r.spectators {
+		p.SendNetMsg(msg)
+	}
+}
+
+// chat goes to the seats and spectators that can read it, the sender included
+func (r *Room) chat(p *Player, chat *unet.ChatMsg) {
+	if !p.checkChat(chat) {
+		return
+	}
+
+	msg, err := unet.EncodeMsg(&unet.ChatMessageMsg{Nick: p.Nickname, Text: chat.Text})
+	if err != nil {
+		fmt.Println("Failed to encode chat message:", err)
+		return
+	}
+
+	for i := range r.seats {
+		if r.seats[i].IsActive() && r.seats[i].conn.HasCapability(unet.CapChat) {
+			r.seats[i].conn.SendNetMsg(msg)
+		}
+	}
+
+	for _, other := range r.spectators {
+		if other.HasCapability(unet.CapChat) {
+			other.SendNetMsg(msg)
+		}
+	}
+}
+
+func (r *Room) sendTo(seatIdx int, m unet.Message) {
+	if seatIdx >= 0 && seatIdx < len(r.seats) && r.seats[seatIdx].IsActive() {
+		r.seats[seatIdx].conn.Send(m)
+	}
+}
+
+// sendTableUpdates pushes the authoritative table to every seat, clients
+// diff it against their own bookkeeping
+func (r *Room) sendTableUpdates() {
+	for i := range r.seats {
+		if !r.seats[i].IsActive() {
+			continue
+		}
+
+		update, err := unet.NewRoomUpdate(r.ID, unet.UpdateTable, r.snapshot(i))
+		if err != nil {
+			fmt.Println("Failed to build room update:", err)
+			continue
+		}
+		r.sendTo(i, update)
+	}
+
+	if len(r.spectators) == 0 {
+		return
+	}
+
+	update, err := unet.NewRoomUpdate(r.ID, unet.UpdateTable, r.snapshot(-1))
+	if err != nil {
+		fmt.Println("Failed to build room update:", err)
+		return
+	}
+	for _, p := range r.spectators {
+		p.Send(update)
+	}
+}
+
+func (r *Room) playerSnapshot(seatIdx int) unet.PlayerSnapshot {
+	seat := &r.seats[seatIdx]
+
+	return unet.PlayerSnapshot{
+		Nick:         seat.Nickname,
+		Chips:        seat.Chips,
+		IsFolded:     seat.IsFolded,
+		IsReady:      seat.IsReady,
+		IsTurn:       seatIdx == r.actor,
+		Action:       seat.Action,
+		ActionAmount: seat.ActionAmount,
+		RoundBet:     seat.RoundBet,
+		TotalBet:     seat.TotalBet,
+	}
+}
+
+// snapshot is the RMST for one seat, only that seat's hand is included.
+// Spectators pass -1 and get no hand at all.
+func (r *Room) snapshot(seatIdx int) *unet.RoomStateMsg {
+	msg := &unet.RoomStateMsg{
+		Pot:            r.pot,
+		HighBet:        r.highBet,
+		CommunityCards: append([]int{}, r.community...),
+	}
+
+	if seatIdx >= 0 && seatIdx < len(r.seats) {
+		seat := &r.seats[seatIdx]
+		msg.CardsDealt = seat.CardsDealt
+		msg.Card1 = seat.Hand[0]
+		msg.Card2 = seat.Hand[1]
+	}
+
+	msg.Seats = make([]string, len(r.seats))
+	for i := range r.seats {
+		if r.seats[i].Occupied {
+			msg.Players = append(msg.Players, r.playerSnapshot(i))
+			msg.Seats[i] = r.seats[i].Nickname
+		}
+	}
+	msg.Dealer = r.dealer
+
+	return msg
+}
+
+func (r *Room) actionMsg(seatIdx int) *unet.PlayerActionMsg {
+	seat := &r.seats[seatIdx]
+
+	return &unet.PlayerActionMsg{
+		Nick:   seat.Nickname,
+		Action: seat.Action,
+		Amount: seat.ActionAmount,
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	unet "poker-client/ups_net"
+)
+
+type LobbyState struct{}
+
+func (s *LobbyState) Name() string { return "Lobby" }
+
+func (s *LobbyState) Enter(r *Room) {
+	fmt.Println("State: Enter Lobby")
+
+	for i := range r.seats {
+		if r.seats[i].Occupied && r.seats[i].conn == nil {
+			fmt.Printf("Lobby cleanup: removing disconnected player from seat %d\n", i)
+			r.seats[i] = Seat{}
+		} else {
+			r.seats[i].resetGame()
+		}
+	}
+
+	r.pot = 0
+	r.highBet = 0
+	r.actor = -1
+	r.community = nil
+	r.deck.Reset()
+	r.locked = false
+}
+
+func (s *LobbyState) Leave(r *Room) {
+	r.locked = true
+	fmt.Println("State: Leave Lobby")
+}
+
+func (s *LobbyState) Tick(r *Room) {
+	for i := range r.seats {
+		if r.seats[i].Occupied && r.seats[i].conn == nil {
+			fmt.Printf("Lobby cleanup: removing disconnected player from seat %d\n", i)
+			r.seats[i] = Seat{}
+		}
+	}
+
+	ready, players, funded := 0, 0, 0
+	for i := range r.seats {
+		if r.seats[i].IsActive() {
+			players++
+			if r.seats[i].IsReady {
+				ready++
+			}
+			if r.seats[i].Chips > 0 {
+				funded++
+			}
+		}
+	}
+
+	// min 2 players that can bet and everyone ready
+	if funded >= 2 && ready == players {
+		fmt.Println("All players are ready, starting game")
+		r.TransitionTo(&DealingState{})
+	}
+}
+
+func (s *LobbyState) Message(r *Room, seatIdx int, msg unet.Message) {
+	switch msg.(type) {
+	case *unet.ReadyMsg:
+		seat := &r.seats[seatIdx]
+		seat.IsReady = true
+		r.sendTo(seatIdx, &unet.ActionOKMsg{})
+		r.broadcastEx(seatIdx, &unet.PlayerReadyMsg{Nick: seat.Nickname})
+		fmt.Printf("Player %s ready\n", seat.Nickname)
+	}
+}
+
+type DealingState struct{}
+
+func (s *DealingState) Name() string { return "Dealing" }
+
+func (s *DealingState) Enter(r *Room) {
+	fmt.Println("State: Enter Dealing")
+	r.broadcast(&unet.GameStartMsg{})
+
+	r.phase = PhasePreFlop
+	r.dealer = r.nextOccupied(r.dealer)
+
+	for i := range r.seats {
+		seat := &r.seats[i]
+		if !seat.IsActive() || !seat.IsReady || seat.Chips <= 0 {
+			// not part of this hand, a broke player would sit in without anything at stake
+			seat.IsFolded = true
+			continue
+		}
+
+		seat.Hand = [2]int{r.deck.Draw(), r.deck.Draw()}
+		seat.CardsDealt = true
+		r.sendTo(i, &unet.CardsToPlayerMsg{Card1: seat.Hand[0], Card2: seat.Hand[1]})
+		fmt.Printf("Dealt cards to %s: %d %d\n", seat.Nickname, seat.Hand[0], seat.Hand[1])
+	}
+}
+
+func (s *DealingState) Leave(r *Room) {}
+
+func (s *DealingState) Tick(r *Room) {
+	r.TransitionTo(&BettingState{})
+}
+
+func (s *DealingState) Message(r *Room, seatIdx int, msg unet.Message) {}
+
+type CommunityCardState struct{}
+
+func (s *CommunityCardState) Name() string { return "CommunityCard" }
+
+func (s *CommunityCardState) Enter(r *Room) {
+	fmt.Println("State: Revealing Community Cards")
+
+	toDraw := 0
+	switch r.phase {
+	case PhasePreFlop:
+		r.phase = PhaseFlop
+		toDraw = 3
+	case PhaseFlop:
+		r.phase = PhaseTurn
+		toDraw = 1
+	case PhaseTurn:
+		r.phase = PhaseRiver
+		toDraw = 1
+	}
+
+	for range toDraw {
+		card := r.deck.Draw()
+		r.community = append(r.community, card)
+		r.broadcast(&unet.CommunityCardMsg{Card: card})
+		fmt.Printf("Revealed community card: %d\n", card)
+	}
+}
+
+func (s *CommunityCardState) Leave(r *Room) {}
+
+func (s *CommunityCardState) Tick(r *Room) {
+	r.TransitionTo(&BettingState{})
+}
+
+func (s *CommunityCardState) Message(r *Room, seatIdx int, msg unet.Message) {}
+
+type BettingState struct {
+	queue      []int
+	lastRaise  int  // a raise has to be at least this much over the high bet
+	betPlaced  bool // without RAIS there is only one bet per round
+	lastAction time.Time
+}
+
+func (s *BettingState) Name() string { return "Betting" }
+
+func (s *BettingState) Enter(r *Room) {
+	fmt.Println("State: Enter Betting")
+
+	s.queue = nil
+	s.lastRaise = 0
+	s.betPlaced = false
+	r.highBet = 0
+	r.broadcast(&unet.GameRoundMsg{})
+
+	for i := range r.seats {
+		seat := &r.seats[i]
+		seat.TotalBet += seat.RoundBet
+		seat.RoundBet = 0
+		seat.ActionAmount = 0
+
+		// folds and leaves stay visible for the rest of the hand
+		if seat.Action == unet.ActionLeft || seat.Action == unet.ActionFold {
+			continue
+		}
+		seat.Action = unet.ActionNone
+	}
+	r.sendTableUpdates()
+
+	// nobody to bet against or everyone else is all-in, just run the board out
+	if r.countCanBet() > 1 {
+		s.queue = r.seatsAfter(r.dealer, true)
+	}
+	s.startNextTurn(r)
+}
+
+func (s *BettingState) Leave(r *Room) {
+	fmt.Println("State: Leave Betting")
+	r.actor = -1
+}
+
+func (s *BettingState) startNextTurn(r *Room) {
+	for len(s.queue) > 0 {
+		idx := s.queue[0]
+		s.queue = s.queue[1:]
+
+		if !r.seats[idx].IsActive() || r.seats[idx].IsFolded {
+			continue
+		}
+
+		r.actor = idx
+		fmt.Printf("Turn: seat %d (%s)\n", idx, r.seats[idx].Nickname)
+		r.broadcast(&unet.PlayerTurnMsg{Nick: r.seats[idx].Nickname})
+		s.lastAction = time.Now()
+		return
+	}
+
+	r.actor = -1
+}
+
+func (s *BettingState) Tick(r *Room) {
+	if r.actor == -1 || r.countInHand() <= 1 {
+		if r.phase == PhaseRiver || r.countInHand() <= 1 {
+			r.TransitionTo(&ShowdownState{})
+		} else {
+			r.TransitionTo(&CommunityCardState{})
+		}
+		return
+	}
+
+	// a player that dropped mid turn is skipped instead of waiting for the timeout
+	if !r.seats[r.actor].IsActive() {
+		s.startNextTurn(r)
+		return
+	}
+
+	if time.Since(s.lastAction) > r.cfg.TurnTimeout {
+		seat := &r.seats[r.actor]
+		seat.IsFolded = true
+		seat.Action = unet.ActionFold
+
+		r.broadcast(&unet.TimeoutMsg{Nick: seat.Nickname})
+		s.startNextTurn(r)
+	}
+}
+
+func (s *BettingState) Message(r *Room, seatIdx int, msg unet.Message) {
+	switch msg.(type) {
+	case *unet.CheckMsg, *unet.FoldMsg, *unet.CallMsg, *unet.BetMsg:
+	default:
+		return
+	}
+
+	if seatIdx != r.actor {
+		r.sendTo(seatIdx, &unet.NotYourTurnMsg{})
+		return
+	}
+
+	seat := &r.seats[seatIdx]
+
+	switch m := msg.(type) {
+	case *unet.FoldMsg:
+		seat.IsFolded = true
+		seat.Action = unet.ActionFold
+		fmt.Printf("Player %s folded\n", seat.Nickname)
+
+	case *unet.CheckMsg:
+		if r.highBet > seat.RoundBet {
+			r.sendTo(seatIdx, &unet.ActionFailMsg{Reason: "Cannot check, must call"})
+			return
+		}
+		seat.Action = unet.ActionCheck
+
+	case *unet.BetMsg:
+		if reason := s.checkBet(r, seat, m.Amount); reason != "" {
+			r.sendTo(seatIdx, &unet.ActionFailMsg{Reason: reason})
+			return
+		}
+
+		// an all-in short of a full raise doesn't change the minimum
+		if raise := m.Amount - r.highBet; raise >= s.lastRaise {
+			s.lastRaise = raise
+		}
+
+		put := m.Amount - seat.RoundBet
+		seat.Action = unet.ActionBet
+		seat.ActionAmount = put
+		seat.RoundBet = m.Amount
+		seat.Chips -= put
+		r.highBet = m.Amount
+		r.pot += put
+		s.betPlaced = true
+
+		// everyone else has to answer the bet
+		s.queue = r.seatsAfter(seatIdx, false)
+		fmt.Printf("Player %s bets %d, round bet %d\n", seat.Nickname, put, m.Amount)
+
+	case *unet.CallMsg:
+		// calling with less than the bet is an all in
+		amount := min(r.highBet-seat.RoundBet, seat.Chips)
+		seat.Chips -= amount
+		seat.RoundBet += amount
+		r.pot += amount
+		seat.Action = unet.ActionCall
+		seat.ActionAmount = amount
+		fmt.Printf("Player %s calls %d\n", seat.Nickname, amount)
+	}
+
+	r.sendTo(seatIdx, &unet.ActionOKMsg{})
+	r.broadcastEx(seatIdx, r.actionMsg(seatIdx))
+	s.startNextTurn(r)
+}
+
+// checkBet takes amount as the new round bet of the seat
+func (s *BettingState) checkBet(r *Room, seat *Seat, amount int) string {
+	if s.betPlaced && !seat.conn.HasCapability(unet.CapRaise) {
+		return "Cannot raise (limit 1 bet/round)"
+	}
+
+	if amount <= 0 {
+		return "Bet has to be positive"
+	}
+
+	if amount-seat.RoundBet > seat.Chips {
+		return "Not enough chips to bet that amount"
+	}
+
+	if r.highBet == 0 {
+		return ""
+	}
+
+	if amount <= r.highBet {
+		return "A raise has to go over the high bet"
+	}
+
+	allIn := amount == seat.RoundBet+seat.Chips
+	if amount < r.highBet+s.lastRaise && !allIn {
+		return fmt.Sprintf("Raise to at least %d", r.highBet+s.lastRaise)
+	}
+
+	return ""
+}
+
+type ShowdownState struct {
+	started time.Time
+}
+
+func (s *ShowdownState) Name() string { return "Showdown" }
+
+func (s *ShowdownState) Enter(r *Room) {
+	fmt.Println("State: Enter Showdown")
+	s.started = time.Now()
+
+	// money still on the table from the last betting round
+	for i := range r.seats {
+		r.seats[i].TotalBet += r.seats[i].RoundBet
+		r.seats[i].RoundBet = 0
+	}
+
+	showdown := &unet.ShowdownMsg{}
+	for i := range r.seats {
+		seat := &r.seats[i]
+		if seat.Occupied && seat.CardsDealt {
+			showdown.Hands = append(showdown.Hands, unet.ShowdownHand{
+				Nick:  seat.Nickname,
+				Card1: seat.Hand[0],
+				Card2: seat.Hand[1],
+			})
+		}
+	}
+	r.broadcast(showdown)
+
+	payouts := r.payouts()
+	if len(payouts) == 0 {
+		r.broadcast(&unet.GameLostMsg{})
+		return
+	}
+
+	// one GWIN per winner, a split or side pot has more than one
+	for i := range r.seats {
+		amount, ok := payouts[i]
+		if !ok {
+			continue
+		}
+
+		seat := &r.seats[i]
+		seat.Chips += amount
+		r.broadcast(&unet.GameWinMsg{Nick: seat.Nickname, Amount: amount})
+		fmt.Printf("Player %s wins %d\n", seat.Nickname, amount)
+	}
+}
+
+func (s *ShowdownState) Leave(r *Room) {}
+
+func (s *ShowdownState) Tick(r *Room) {
+	accepted := 0
+	for i := range r.seats {
+		if r.seats[i].ShowdownOK && r.seats[i].IsActive() {
+			accepted++
+		}
+	}
+
+	if accepted == r.countActive() || time.Since(s.started) > r.cfg.SDTimeout {
+		r.broadcast(&unet.GameDoneMsg{})
+		r.TransitionTo(&LobbyState{})
+	}
+}
+
+func (s *ShowdownState) Message(r *Room, seatIdx int, msg unet.Message) {
+	if _, ok := msg.(*unet.ShowdownOKMsg); ok {
+		r.seats[seatIdx].ShowdownOK = true
+	}
+}
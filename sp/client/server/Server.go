@@ -0,0 +1,436 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	unet "poker-client/ups_net"
+)
+
+const (
+	msgBatchSize = 10
+	tickInterval = 10 * time.Millisecond
+
+	// how often the room list is checked for changes to push to the lobby
+	roomUpdateInterval = 250 * time.Millisecond
+)
+
+// Capabilities this server is willing to confirm in PNOK/RCON
+var serverCapabilities = []string{
+	unet.CapExtendedFrames,
+	unet.CapChat,
+	unet.CapRaise,
+	unet.CapSpectate,
+}
+
+type Config struct {
+	MaxPlayers  int
+	RoomCount   int
+	RoomSeats   int
+	TurnTimeout time.Duration
+	SDTimeout   time.Duration
+	PingTimeout time.Duration
+}
+
+// Server owns the players that are not in a room, rooms hand them back through ReturnPlayer
+type Server struct {
+	cfg Config
+
+	players   []*Player
+	playerMtx sync.Mutex
+	rooms     []*Room
+
+	// what lobby players were last told about each room
+	roomInfo []unet.RoomMsg
+
+	lastPing       time.Time
+	lastRoomUpdate time.Time
+}
+
+func NewServer(cfg Config) (*Server, error) {
+	if cfg.MaxPlayers <= 0 {
+		return nil, fmt.Errorf("max players must be greater than 0")
+	}
+
+	if cfg.RoomSeats < 2 {
+		return nil, fmt.Errorf("rooms need at least 2 seats")
+	}
+
+	s := &Server{cfg: cfg, lastPing: time.Now()}
+	for i := range cfg.RoomCount {
+		room := NewRoom(i, fmt.Sprintf("Room %d", i), s, cfg)
+		s.rooms = append(s.rooms, room)
+		s.roomInfo = append(s.roomInfo, *room.Info())
+	}
+
+	return s, nil
+}
+
+// Run accepts connections until the listener is closed
+func (s *Server) Run(listener net.Listener) error {
+	for _, room := range s.rooms {
+		go room.Run()
+	}
+	go s.processLogic()
+
+	for {
+		fmt.Println("Waiting for new connection...")
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		player := NewPlayer(conn)
+
+		s.playerMtx.Lock()
+		if len(s.players) >= s.cfg.MaxPlayers {
+			s.playerMtx.Unlock()
+			player.Send(&unet.FullMsg{})
+			player.Disconnect()
+			continue
+		}
+
+		s.players = append(s.players, player)
+		s.playerMtx.Unlock()
+		fmt.Println("New connection accepted from", conn.RemoteAddr())
+	}
+}
+
+// ReturnPlayer gives a player back to the main list, eg. after GMLV
+func (s *Server) ReturnPlayer(p *Player) {
+	s.playerMtx.Lock()
+	defer s.playerMtx.Unlock()
+
+	p.State = PlayerAwaitingJoin
+	s.players = append(s.players, p)
+}
+
+func (s *Server) processLogic() {
+	for {
+		if time.Since(s.lastPing) > s.cfg.PingTimeout {
+			s.lastPing = time.Now()
+			s.pingPlayers()
+		}
+
+		s.playerMtx.Lock()
+		players := s.players[:0]
+		for _, p := range s.players {
+			if p.IsConnected() {
+				players = append(players, p)
+			}
+		}
+		s.players = players
+		snapshot := append([]*Player(nil), s.players...)
+		s.playerMtx.Unlock()
+
+		for _, p := range snapshot {
+			room := s.processPlayerMessages(p)
+			if room == nil {
+				continue
+			}
+
+			s.removePlayer(p)
+			room.AcceptPlayer(p)
+		}
+
+		if time.Since(s.lastRoomUpdate) > roomUpdateInterval {
+			s.lastRoomUpdate = time.Now()
+			s.sendRoomUpdates()
+		}
+
+		time.Sleep(tickInterval)
+	}
+}
+
+// sendRoomUpdates diffs every room against what the lobby last saw and
+// sends RMUP for the changes to players that already have the list
+func (s *Server) sendRoomUpdates() {
+	updates := make([]*unet.RoomUpdateMsg, 0)
+	add := func(roomID int, member int, v any) {
+		update, err := unet.NewRoomUpdate(roomID, member, v)
+		if err != nil {
+			fmt.Println("Failed to build room update:", err)
+			return
+		}
+		updates = append(updates, update)
+	}
+
+	for i, room := range s.rooms {
+		info := *room.Info()
+		last := s.roomInfo[i]
+
+		if info.Name != last.Name {
+			add(info.ID, unet.UpdateRoomName, unet.RoomNameUpdate{Name: info.Name})
+		}
+		if info.CurrentPlayers != last.CurrentPlayers {
+			add(info.ID, unet.UpdateRoomPlayers, unet.RoomCountUpdate{Count: info.CurrentPlayers})
+		}
+		if info.MaxPlayers != last.MaxPlayers {
+			add(info.ID, unet.UpdateRoomMax, unet.RoomCountUpdate{Count: info.MaxPlayers})
+		}
+		s.roomInfo[i] = info
+	}
+
+	if len(updates) == 0 {
+		return
+	}
+
+	// not the snapshot, a room may have handed a player back since
+	s.playerMtx.Lock()
+	defer s.playerMtx.Unlock()
+
+	for _, p := range s.players {
+		// the rest gets the current values with the next room list
+		if p.State != PlayerAwaitingJoin || !p.IsConnected() {
+			continue
+		}
+
+		for _, update := range updates {
+			p.Send(update)
+		}
+	}
+}
+
+func (s *Server) pingPlayers() {
+	s.playerMtx.Lock()
+	defer s.playerMtx.Unlock()
+
+	for _, p := range s.players {
+		if !p.IsConnected() {
+			continue
+		}
+
+		if !p.GotPing() {
+			fmt.Printf("Player %s didn't send ping\n", p.Nickname)
+			p.Disconnect()
+			continue
+		}
+
+		p.ClearPing()
+		p.SendPing()
+	}
+}
+
+func (s *Server) removePlayer(p *Player) {
+	s.playerMtx.Lock()
+	defer s.playerMtx.Unlock()
+
+	for i, other := range s.players {
+		if other == p {
+			s.players = append(s.players[:i], s.players[i+1:]...)
+			return
+		}
+	}
+}
+
+// processPlayerMessages returns the room the player should be moved to, if any
+func (s *Server) processPlayerMessages(p *Player) *Room {
+	for range msgBatchSize {
+		raw, ok := p.Read()
+		if !ok {
+			return nil
+		}
+
+		fmt.Printf("Processing %s for state %d from %s\n", raw.Code, p.State, p.Nickname)
+
+		msg, err := unet.DecodeMsg(raw)
+		if err != nil {
+			fmt.Printf("Bad message from %s, disconnecting: %v\n", p.conn.RemoteAddr(), err)
+			p.Send(&unet.FailMsg{})
+			p.Disconnect()
+			return nil
+		}
+
+		switch p.State {
+		case PlayerConnected:
+			conn, ok := msg.(*unet.ConnMsg)
+			if !ok {
+				fmt.Printf("Unexpected %s in Connected state\n", raw.Code)
+				p.Disconnect()
+				return nil
+			}
+			s.handleConn(p, conn)
+
+		case PlayerAwaitingReconnect:
+			switch m := msg.(type) {
+			case *unet.ReconnectMsg:
+				fmt.Printf("Player %s accepted reconnect\n", p.Nickname)
+				p.State = PlayerInRoom
+				return s.rooms[p.reconnectRoom]
+			case *unet.InfoMsg:
+				s.handleInfo(p, m)
+			default:
+				fmt.Printf("Unexpected %s in AwaitingReconnect state\n", raw.Code)
+				p.Disconnect()
+				return nil
+			}
+
+		case PlayerAwaitingInfo:
+			info, ok := msg.(*unet.InfoMsg)
+			if !ok {
+				fmt.Printf("Unexpected %s in AwaitingInfo state\n", raw.Code)
+				p.Disconnect()
+				return nil
+			}
+			s.handleInfo(p, info)
+
+		case PlayerSendingRooms:
+			switch msg.(type) {
+			case *unet.RoomOKMsg:
+				s.sendRoomInfo(p)
+			case *unet.DoneOKMsg:
+				p.State = PlayerAwaitingJoin
+			case *unet.RoomFailMsg, *unet.DoneFailMsg:
+				fmt.Printf("Client %s failed to read the room list\n", p.Nickname)
+				p.Disconnect()
+				return nil
+			default:
+				fmt.Printf("Unexpected %s in SendingRooms state\n", raw.Code)
+				p.Disconnect()
+				return nil
+			}
+
+		case PlayerAwaitingJoin:
+			switch m := msg.(type) {
+			case *unet.JoinMsg:
+				return s.handleJoin(p, m)
+			case *unet.SpectateMsg:
+				return s.handleSpectate(p, m)
+			case *unet.ChatMsg:
+				s.handleChat(p, m)
+			case *unet.RoomRequestMsg:
+				p.State = PlayerSendingRooms
+				p.roomSendIndex = 0
+				s.sendRoomInfo(p)
+			case *unet.UpdateOKMsg:
+			case *unet.UpdateFailMsg:
+				// not fatal, the next RMRQ gets the full list
+				fmt.Printf("Client %s failed to apply a room update\n", p.Nickname)
+			default:
+				fmt.Printf("Unexpected %s in AwaitingJoin state\n", raw.Code)
+				p.Disconnect()
+				return nil
+			}
+
+		case PlayerInRoom, PlayerSpectating:
+			fmt.Println("Player in InRoom state but still in main list, disconnecting")
+			p.Disconnect()
+			return nil
+		}
+
+		if !p.IsConnected() {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) handleConn(p *Player, conn *unet.ConnMsg) {
+	p.Nickname = conn.Nick
+	version, capabilities := p.negotiate(conn)
+
+	for i, room := range s.rooms {
+		if room.HasDisconnectedSeat(p.Nickname) {
+			fmt.Printf("Reconnect candidate %s found in room %d\n", p.Nickname, i)
+			p.reconnectRoom = i
+			p.State = PlayerAwaitingReconnect
+			p.Send(&unet.ReconnectMsg{Version: version, Capabilities: capabilities})
+			return
+		}
+	}
+
+	fmt.Printf("New player %s connected\n", p.Nickname)
+	p.State = PlayerAwaitingInfo
+	p.Send(&unet.NickOKMsg{Version: version, Capabilities: capabilities})
+}
+
+func (s *Server) handleInfo(p *Player, info *unet.InfoMsg) {
+	p.Chips = info.Chips
+	fmt.Printf("Received player info from %s | %d\n", p.Nickname, p.Chips)
+
+	p.State = PlayerAwaitingJoin
+	p.Send(&unet.InfoOKMsg{})
+}
+
+func (s *Server) sendRoomInfo(p *Player) {
+	p.State = PlayerSendingRooms
+
+	if p.roomSendIndex < len(s.rooms) {
+		room := s.rooms[p.roomSendIndex]
+		p.roomSendIndex++
+		p.Send(room.Info())
+		return
+	}
+
+	fmt.Printf("Done sending rooms to %s\n", p.Nickname)
+	p.Send(&unet.RoomsDoneMsg{})
+}
+
+func (s *Server) handleJoin(p *Player, join *unet.JoinMsg) *Room {
+	for _, room := range s.rooms {
+		if room.ID != join.RoomID {
+			continue
+		}
+
+		if !room.CanJoin(p.Nickname) {
+			fmt.Printf("Room %d full, rejecting %s\n", join.RoomID, p.Nickname)
+			p.Send(&unet.JoinFailMsg{})
+			return nil
+		}
+
+		fmt.Printf("Accepted %s into room %d\n", p.Nickname, join.RoomID)
+		p.State = PlayerInRoom
+		p.Send(&unet.JoinOKMsg{})
+		return room
+	}
+
+	fmt.Printf("Room %d not found for %s\n", join.RoomID, p.Nickname)
+	p.Send(&unet.JoinFailMsg{})
+	return nil
+}
+
+// handleSpectate never fails for an existing room, spectators don't take a seat
+func (s *Server) handleSpectate(p *Player, spec *unet.SpectateMsg) *Room {
+	if !p.HasCapability(unet.CapSpectate) {
+		fmt.Printf("Rejected spectating from %s, SPEC wasn't agreed on\n", p.Nickname)
+		p.Send(&unet.SpectateFailMsg{})
+		return nil
+	}
+
+	for _, room := range s.rooms {
+		if room.ID != spec.RoomID {
+			continue
+		}
+
+		fmt.Printf("%s is spectating room %d\n", p.Nickname, spec.RoomID)
+		p.State = PlayerSpectating
+		p.Send(&unet.SpectateOKMsg{})
+		return room
+	}
+
+	fmt.Printf("Room %d not found for spectator %s\n", spec.RoomID, p.Nickname)
+	p.Send(&unet.SpectateFailMsg{})
+	return nil
+}
+
+// handleChat passes lobby chat on to everyone in the room list
+func (s *Server) handleChat(p *Player, chat *unet.ChatMsg) {
+	if !p.checkChat(chat) {
+		return
+	}
+
+	msg := &unet.ChatMessageMsg{Nick: p.Nickname, Text: chat.Text}
+
+	s.playerMtx.Lock()
+	defer s.playerMtx.Unlock()
+
+	// the sender gets it too, that is their OK
+	for _, other := range s.players {
+		if other.State == PlayerAwaitingJoin && other.IsConnected() && other.HasCapability(unet.CapChat) {
+			other.Send(msg)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"maps"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	unet "poker-client/ups_net"
+)
+
+// testClient speaks the protocol by hand, only what a test needs
+type testClient struct {
+	t    *testing.T
+	nick string
+	conn net.Conn
+	r    *unet.FrameReader
+	w    *unet.FrameWriter
+}
+
+func startTestServer(t *testing.T, seats int) (*Server, string) {
+	t.Helper()
+
+	server, err := NewServer(Config{
+		MaxPlayers:  8,
+		RoomCount:   1,
+		RoomSeats:   seats,
+		TurnTimeout: time.Minute,
+		SDTimeout:   time.Minute,
+		PingTimeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go server.Run(listener)
+	return server, listener.Addr().String()
+}
+
+// connectClient goes through CONN, PINF and the room list and joins room 0
+func connectClient(t *testing.T, addr, nick string, chips int, capabilities ...string) *testClient {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := &testClient{t: t, nick: nick, conn: conn, r: unet.NewFrameReader(conn), w: unet.NewFrameWriter(conn)}
+
+	c.send(&unet.ConnMsg{Nick: nick, Version: unet.ProtocolVersion, Capabilities: capabilities})
+	nickOK := expect[*unet.NickOKMsg](c)
+	for _, capability := range capabilities {
+		if !slices.Contains(nickOK.Capabilities, capability) {
+			t.Fatalf("%s: server didn't confirm %s, got %v", nick, capability, nickOK.Capabilities)
+		}
+	}
+
+	c.send(&unet.InfoMsg{Chips: chips})
+	expect[*unet.InfoOKMsg](c)
+
+	c.send(&unet.RoomRequestMsg{})
+	for {
+		msg := c.next(unet.CodeRoom, unet.CodeRoomsDone)
+		if _, ok := msg.(*unet.RoomsDoneMsg); ok {
+			c.send(&unet.DoneOKMsg{})
+			break
+		}
+		c.send(&unet.RoomOKMsg{})
+	}
+
+	c.send(&unet.JoinMsg{RoomID: 0})
+	expect[*unet.JoinOKMsg](c)
+	expect[*unet.RoomStateMsg](c)
+
+	return c
+}
+
+func (c *testClient) send(m unet.Message) {
+	c.t.Helper()
+
+	msg, err := unet.EncodeMsg(m)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.w.WriteFrame(msg); err != nil {
+		c.t.Fatalf("%s: send %s: %v", c.nick, msg.Code, err)
+	}
+}
+
+// next skips everything until one of codes shows up
+func (c *testClient) next(codes ...string) unet.Message {
+	c.t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		raw, err := c.r.ReadFrame()
+		if err != nil {
+			c.t.Fatalf("%s: waiting for %v: %v", c.nick, codes, err)
+		}
+
+		if !slices.Contains(codes, raw.Code) {
+			continue
+		}
+
+		msg, err := unet.DecodeMsg(raw)
+		if err != nil {
+			c.t.Fatalf("%s: %v", c.nick, err)
+		}
+		return msg
+	}
+}
+
+func expect[T unet.Message](c *testClient) T {
+	c.t.Helper()
+
+	var want T
+	msg := c.next(want.Code())
+	return msg.(T)
+}
+
+// stackDeck makes the next hand deal cards in this order, two per seat and then the board
+func stackDeck(room *Room, cards ...int) {
+	room.mtx.Lock()
+	defer room.mtx.Unlock()
+
+	room.deck.cards = slices.Clone(cards)
+	slices.Reverse(room.deck.cards)
+}
+
+// refused is a scripted action the server has to answer with ACFL
+type refused struct{ unet.Message }
+
+// playHand readies everyone and answers every PTRN from the scripts, it
+// returns the GWIN amounts. Turns nobody scripted fail the test.
+func playHand(t *testing.T, clients []*testClient, scripts map[string][]unet.Message) map[string]int {
+	t.Helper()
+
+	byNick := map[string]*testClient{}
+	for _, c := range clients {
+		byNick[c.nick] = c
+		c.send(&unet.ReadyMsg{})
+		expect[*unet.ActionOKMsg](c)
+	}
+
+	// the first client watches the broadcasts for everyone
+	observer := clients[0]
+	for {
+		msg := observer.next(unet.CodePlayerTurn, unet.CodeShowdown)
+		if _, ok := msg.(*unet.ShowdownMsg); ok {
+			break
+		}
+
+		nick := msg.(*unet.PlayerTurnMsg).Nick
+		if len(scripts[nick]) == 0 {
+			t.Fatalf("unexpected turn for %s", nick)
+		}
+
+		actor := byNick[nick]
+		for {
+			if len(scripts[nick]) == 0 {
+				t.Fatalf("%s has nothing left to try", nick)
+			}
+
+			action := scripts[nick][0]
+			scripts[nick] = scripts[nick][1:]
+
+			r, mustFail := action.(refused)
+			if mustFail {
+				action = r.Message
+			}
+
+			actor.send(action)
+			fail, failed := actor.next(unet.CodeActionOK, unet.CodeActionFail).(*unet.ActionFailMsg)
+			switch {
+			case failed && !mustFail:
+				t.Fatalf("%s: action refused: %s", nick, fail.Reason)
+			case !failed && mustFail:
+				t.Fatalf("%s: %s should have been refused", nick, r.Code())
+			}
+
+			// still the same turn after an ACFL
+			if !failed {
+				break
+			}
+		}
+	}
+
+	for nick, script := range scripts {
+		if len(script) > 0 {
+			t.Errorf("%s still had %d actions left", nick, len(script))
+		}
+	}
+
+	for _, c := range clients[1:] {
+		expect[*unet.ShowdownMsg](c)
+	}
+	for _, c := range clients {
+		c.send(&unet.ShowdownOKMsg{})
+	}
+
+	wins := map[string]int{}
+	for {
+		msg := observer.next(unet.CodeGameWin, unet.CodeGameLost, unet.CodeGameDone)
+		if _, ok := msg.(*unet.GameDoneMsg); ok {
+			break
+		}
+		if win, ok := msg.(*unet.GameWinMsg); ok {
+			if _, dup := wins[win.Nick]; dup {
+				t.Errorf("second GWIN for %s", win.Nick)
+			}
+			wins[win.Nick] = win.Amount
+		}
+	}
+
+	// everyone else is lined up for the next hand
+	for _, c := range clients[1:] {
+		expect[*unet.GameDoneMsg](c)
+	}
+
+	return wins
+}
+
+func checkChips(t *testing.T, room *Room, want map[string]int) {
+	t.Helper()
+
+	room.mtx.Lock()
+	defer room.mtx.Unlock()
+
+	for _, seat := range room.seats {
+		if amount, ok := want[seat.Nickname]; ok && seat.Chips != amount {
+			t.Errorf("%s has %d chips, want %d", seat.Nickname, seat.Chips, amount)
+		}
+	}
+}
+
+func TestServerGame(t *testing.T) {
+	server, addr := startTestServer(t, 3)
+	room := server.rooms[0]
+
+	// joined one after the other, so alice is seat 0, bob 1 and carol 2
+	alice := connectClient(t, addr, "alice", 10, unet.CapRaise)
+	bob := connectClient(t, addr, "bob", 100, unet.CapRaise)
+	carol := connectClient(t, addr, "carol", 100, unet.CapRaise)
+	clients := []*testClient{alice, bob, carol}
+
+	check := &unet.CheckMsg{}
+	call := &unet.CallMsg{}
+
+	// the button goes to bob, carol acts first. Alice is all-in with the best
+	// hand and takes the main pot, bob and carol tie on K-Q-J-9-7 for the side pot.
+	// Nobody asks alice for anything once she is all-in.
+	stackDeck(room,
+		12, 25, // alice: Ah Ad
+		36, 41, // bob: Qc 4s
+		23, 28, // carol: Qd 4c
+		0, 18, 33, // flop: 2h 7d 9c
+		48, // turn: Js
+		11, // river: Kh
+	)
+	wins := playHand(t, clients, map[string][]unet.Message{
+		"carol": {&unet.BetMsg{Amount: 50}, check, check, check},
+		"alice": {call},
+		"bob":   {call, check, check, check},
+	})
+
+	if want := map[string]int{"alice": 30, "bob": 40, "carol": 40}; !maps.Equal(wins, want) {
+		t.Errorf("side pot hand paid %v, want %v", wins, want)
+	}
+	checkChips(t, room, map[string]int{"alice": 30, "bob": 90, "carol": 90})
+
+	// a royal flush on the board, everybody splits
+	stackDeck(room,
+		0, 1, // alice
+		2, 3, // bob
+		4, 5, // carol
+		47, 48, 49, // Ts Js Qs
+		50, // Ks
+		51, // As
+	)
+	wins = playHand(t, clients, map[string][]unet.Message{
+		"alice": {&unet.BetMsg{Amount: 20}, check, check, check},
+		"bob":   {call, check, check, check},
+		"carol": {call, check, check, check},
+	})
+
+	if want := map[string]int{"alice": 20, "bob": 20, "carol": 20}; !maps.Equal(wins, want) {
+		t.Errorf("tied hand paid %v, want %v", wins, want)
+	}
+	checkChips(t, room, map[string]int{"alice": 30, "bob": 90, "carol": 90})
+}
+
+func TestServerRaiseNeedsCapability(t *testing.T) {
+	server, addr := startTestServer(t, 2)
+	room := server.rooms[0]
+
+	// bob is a client from before RAIS, the server has to hold him to one bet per round
+	alice := connectClient(t, addr, "alice", 100, unet.CapRaise)
+	bob := connectClient(t, addr, "bob", 100)
+
+	check := &unet.CheckMsg{}
+	call := &unet.CallMsg{}
+
+	stackDeck(room,
+		12, 25, // alice: Ah Ad
+		36, 41, // bob: Qc 4s
+		0, 18, 33, // flop: 2h 7d 9c
+		48, // turn: Js
+		11, // river: Kh
+	)
+	wins := playHand(t, []*testClient{alice, bob}, map[string][]unet.Message{
+		"alice": {check, check, &unet.BetMsg{Amount: 30}, check, check},
+		"bob":   {check, &unet.BetMsg{Amount: 10}, refused{&unet.BetMsg{Amount: 60}}, call, check, check},
+	})
+
+	if want := map[string]int{"alice": 60}; !maps.Equal(wins, want) {
+		t.Errorf("paid %v, want %v", wins, want)
+	}
+	checkChips(t, room, map[string]int{"alice": 130, "bob": 70})
+}
+
+// inLobby waits until the room handed the player back to the main list
+func inLobby(t *testing.T, server *Server, nick string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		server.playerMtx.Lock()
+		back := slices.ContainsFunc(server.players, func(p *Player) bool { return p.Nickname == nick })
+		server.playerMtx.Unlock()
+
+		if back {
+			return
+		}
+		time.Sleep(tickInterval)
+	}
+	t.Fatalf("%s never got back to the lobby", nick)
+}
+
+func TestServerRoomHandover(t *testing.T) {
+	server, addr := startTestServer(t, 3)
+
+	// alice stays seated, bob keeps walking in and out so the lobby has room
+	// updates to send while rooms take players and give them back
+	connectClient(t, addr, "alice", 100)
+	bob := connectClient(t, addr, "bob", 100, unet.CapSpectate)
+
+	for start := time.Now(); time.Since(start) < 3*roomUpdateInterval; {
+		bob.send(&unet.LeaveMsg{})
+		inLobby(t, server, "bob")
+
+		bob.send(&unet.SpectateMsg{RoomID: 0})
+		expect[*unet.SpectateOKMsg](bob)
+		expect[*unet.RoomStateMsg](bob)
+		bob.send(&unet.LeaveMsg{})
+		inLobby(t, server, "bob")
+
+		bob.send(&unet.JoinMsg{RoomID: 0})
+		expect[*unet.JoinOKMsg](bob)
+		expect[*unet.RoomStateMsg](bob)
+	}
+}
+
+func TestServerSpectateNeedsCapability(t *testing.T) {
+	server, addr := startTestServer(t, 2)
+
+	// bob never offered SPEC, he is refused and stays in the room list
+	bob := connectClient(t, addr, "bob", 100)
+	bob.send(&unet.LeaveMsg{})
+	inLobby(t, server, "bob")
+
+	bob.send(&unet.SpectateMsg{RoomID: 0})
+	expect[*unet.SpectateFailMsg](bob)
+
+	bob.send(&unet.JoinMsg{RoomID: 0})
+	expect[*unet.JoinOKMsg](bob)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// the servers have no blinds, any positive bet opens
+const minBet = 1
+
+// betPreset is one of the sizing buttons next to the slider
+type betPreset struct {
+	id       string
+	label    string
+	fraction float64 // of the pot after calling
+	allIn    bool
+}
+
+var betPresets = []betPreset{
+	{id: "Game_BetHalfPot", label: "1/2 pot", fraction: 0.5},
+	{id: "Game_BetThreeQuarterPot", label: "3/4 pot", fraction: 0.75},
+	{id: "Game_BetPot", label: "Pot", fraction: 1},
+	{id: "Game_BetAllIn", label: "All-in", allIn: true},
+}
+
+// betLimits are the smallest and the largest amount the round bet can be
+// brought to. A raise has to be at least as big as the last bet or raise.
+func betLimits(table PokerTable, me PlayerData) (minTo, maxTo int) {
+	maxTo = me.RoundBet + me.ChipCount
+	if table.HighBet == 0 {
+		return minBet, maxTo
+	}
+
+	// not known after a reconnect, the bet itself is the safe guess
+	raise := table.LastRaise
+	if raise <= 0 {
+		raise = table.HighBet
+	}
+	return table.HighBet + raise, maxTo
+}
+
+// placeBet books a bet or raise of put chips, it tells if it was a raise
+func (t *PokerTable) placeBet(nick string, put int) bool {
+	player := t.Players[nick]
+	raised := t.HighBet > 0
+
+	player.putChips(put)
+	player.ActionTaken, player.ActionAmount = "BETT", put
+	t.Players[nick] = player
+	t.Pot += put
+
+	// a short all-in doesn't set a new minimum, same as on the server
+	if raise := player.RoundBet - t.HighBet; raise >= t.LastRaise {
+		t.LastRaise = raise
+	}
+	t.HighBet = max(t.HighBet, player.RoundBet)
+
+	return raised
+}
+
+// checkBet returns why amount can't be bet, going all-in is always fine
+func checkBet(table PokerTable, me PlayerData, amount int) string {
+	minTo, maxTo := betLimits(table, me)
+	switch {
+	case amount > maxTo:
+		return fmt.Sprintf("You only have %d chips", me.ChipCount)
+	case table.HighBet > 0 && amount <= table.HighBet:
+		return fmt.Sprintf("A raise has to go over %d", table.HighBet)
+	case amount < minTo && amount != maxTo && table.HighBet > 0:
+		return fmt.Sprintf("Raise to at least %d", minTo)
+	case amount < minTo && amount != maxTo:
+		return fmt.Sprintf("The bet has to be at least %d", minTo)
+	}
+	return ""
+}
+
+// presetAmount sizes a bet as a fraction of the pot, counting the call first
+// the way pot-limit does
+func presetAmount(table PokerTable, me PlayerData, preset betPreset) int {
+	minTo, maxTo := betLimits(table, me)
+	if preset.allIn {
+		return maxTo
+	}
+
+	toCall := max(table.HighBet-me.RoundBet, 0)
+	amount := table.HighBet + int(preset.fraction*float64(table.Pot+toCall))
+	return min(max(amount, minTo), maxTo)
+}
+
+func findBetPreset(id string) (betPreset, bool) {
+	for _, preset := range betPresets {
+		if preset.id == id {
+			return preset, true
+		}
+	}
+	return betPreset{}, false
+}
+
+// applyBetPreset fills the bet box and the slider
+func applyBetPreset(ctx *ProgCtx, preset betPreset) {
+	ctx.StateMutex.Lock()
+	defer ctx.StateMutex.Unlock()
+
+	me, ok := ctx.State.Table.Players[ctx.State.Nickname]
+	if !ok {
+		return
+	}
+
+	ctx.State.BetSize = presetAmount(ctx.State.Table, me, preset)
+	ctx.State.BetAmount = strconv.Itoa(ctx.State.BetSize)
+}
+
+// syncBetSize moves the slider to what was typed in the bet box
+func syncBetSize(ctx *ProgCtx) {
+	ctx.StateMutex.Lock()
+	defer ctx.StateMutex.Unlock()
+
+	if amount, err := strconv.Atoi(strings.TrimSpace(ctx.State.BetAmount)); err == nil {
+		ctx.State.BetSize = amount
+	}
+}
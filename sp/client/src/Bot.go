@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	unet "poker-client/ups_net"
+)
+
+const (
+	botTick       = 100 * time.Millisecond
+	botRetryDelay = 2 * time.Second
+	botReconnect  = 5 * time.Second
+	botRefresh    = 3 * time.Second
+)
+
+// TurnView is what a Strategy gets to see when it's the bot's turn
+type TurnView struct {
+	Chips     int
+	RoundBet  int
+	HighBet   int
+	Pot       int
+	Hand      []Card
+	Community []Card
+	Phase     string
+	CanRaise  bool // the server takes BETT over the high bet
+
+	// the last decision for this turn was not accepted by the server
+	Retry bool
+}
+
+// Strategy picks one of CHCK/CALL/BETT/FOLD for the bot
+type Strategy interface {
+	Decide(view TurnView) EvtGameAction
+}
+
+var strategies = map[string]func(r *rand.Rand) Strategy{
+	"passive":    func(r *rand.Rand) Strategy { return PassiveStrategy{} },
+	"aggressive": func(r *rand.Rand) Strategy { return AggressiveStrategy{rng: r} },
+	"random":     func(r *rand.Rand) Strategy { return RandomStrategy{rng: r} },
+}
+
+func strategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PassiveStrategy never puts money in on its own, it checks or calls
+type PassiveStrategy struct{}
+
+func (PassiveStrategy) Decide(view TurnView) EvtGameAction {
+	if view.HighBet > view.RoundBet {
+		return EvtGameAction{Action: unet.CodeCall}
+	}
+	return EvtGameAction{Action: unet.CodeCheck}
+}
+
+// AggressiveStrategy opens every round it can and calls everything else
+type AggressiveStrategy struct {
+	rng *rand.Rand
+}
+
+func (s AggressiveStrategy) Decide(view TurnView) EvtGameAction {
+	if view.HighBet > view.RoundBet || view.Retry {
+		return EvtGameAction{Action: unet.CodeCall}
+	}
+
+	// somewhere between a tenth and a half of the stack
+	amount := view.Chips/10 + s.rng.Intn(view.Chips/2+1)
+	return betAction(amount, view)
+}
+
+// RandomStrategy picks any action, good for shaking out server edge cases
+type RandomStrategy struct {
+	rng *rand.Rand
+}
+
+func (s RandomStrategy) Decide(view TurnView) EvtGameAction {
+	if view.Retry {
+		return PassiveStrategy{}.Decide(view)
+	}
+
+	switch s.rng.Intn(4) {
+	case 0:
+		return EvtGameAction{Action: unet.CodeFold}
+	case 1:
+		return EvtGameAction{Action: unet.CodeCall}
+	case 2:
+		return betAction(1+s.rng.Intn(max(view.Chips, 1)), view)
+	default:
+		return EvtGameAction{Action: unet.CodeCheck}
+	}
+}
+
+// betAction falls back to a call if the bot can't afford to bet
+func betAction(amount int, view TurnView) EvtGameAction {
+	amount = min(amount, view.Chips)
+	if amount <= 0 {
+		return EvtGameAction{Action: unet.CodeCall}
+	}
+
+	return EvtGameAction{Action: unet.CodeBet, Amount: strconv.Itoa(amount)}
+}
+
+// Bot plays in place of the UI: it looks at GameState and sends the same
+// UserInputEvents the buttons would
+type Bot struct {
+	strategy Strategy
+	roomID   int // -1 means any room with a free seat
+	chips    int
+
+	lastConnect time.Time
+	lastRefresh time.Time
+	lastReady   time.Time
+	lastAction  time.Time
+	acted       bool
+	showdownOK  bool
+}
+
+func NewBot(strategy Strategy, roomID int, chips int) *Bot {
+	return &Bot{strategy: strategy, roomID: roomID, chips: chips}
+}
+
+func runBot(ctx *ProgCtx, bot *Bot) {
+	for !ctx.ShouldClose {
+		ctx.StateMutex.Lock()
+		evt := bot.step(ctx)
+		ctx.StateMutex.Unlock()
+
+		// nobody draws them, so they have to be expired here
+		ctx.Popup.Update()
+
+		if evt != nil {
+			ctx.UserInputChan <- evt
+		}
+
+		time.Sleep(botTick)
+	}
+}
+
+// step runs under the state lock and returns at most one event
+func (b *Bot) step(ctx *ProgCtx) UserInputEvent {
+	state := &ctx.State
+
+	switch state.Screen {
+	case ScreenMainMenu:
+		if time.Since(b.lastConnect) < botReconnect {
+			return nil
+		}
+		b.lastConnect = time.Now()
+
+		// same as MainMenu_ConnectBtn, the chips go out with INFO
+		state.Table.Players[state.Nickname] = PlayerData{ChipCount: b.chips}
+		return EvtConnect{Host: state.ServerIP, Port: state.ServerPort}
+
+	case ScreenReconnecting:
+		return EvtAcceptReconnect{}
+
+	case ScreenRoomSelect:
+		if id, ok := b.pickRoom(state.Rooms); ok {
+			return EvtRoomJoin{RoomID: strconv.Itoa(id)}
+		}
+
+		if time.Since(b.lastRefresh) < botRefresh {
+			return nil
+		}
+		b.lastRefresh = time.Now()
+		return EvtRefreshRooms{}
+
+	case ScreenInGame:
+		return b.playStep(state, ctx.NetHandler.HasCapability(unet.CapRaise))
+	}
+
+	return nil
+}
+
+func (b *Bot) pickRoom(rooms map[int]Room) (int, bool) {
+	ids := make([]int, 0, len(rooms))
+	for id := range rooms {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		room := rooms[id]
+		if b.roomID >= 0 && id != b.roomID {
+			continue
+		}
+
+		if room.CurrentPlayers < room.MaxPlayers {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+func (b *Bot) playStep(state *GameState, canRaise bool) UserInputEvent {
+	me, ok := state.Table.Players[state.Nickname]
+	if !ok {
+		return nil
+	}
+
+	if !state.Showdown {
+		b.showdownOK = false
+	} else if !b.showdownOK {
+		b.showdownOK = true
+		return EvtGameAction{Action: unet.CodeShowdownOK}
+	}
+
+	if state.Table.RoundPhase == "" && !me.IsReady {
+		if time.Since(b.lastReady) < botRetryDelay {
+			return nil
+		}
+		b.lastReady = time.Now()
+		return EvtGameAction{Action: unet.CodeReady}
+	}
+
+	if !me.IsMyTurn {
+		b.acted = false
+		return nil
+	}
+
+	retry := b.acted
+	if retry && time.Since(b.lastAction) < botRetryDelay {
+		return nil
+	}
+
+	view := TurnView{
+		Chips:     me.ChipCount,
+		RoundBet:  me.RoundBet,
+		HighBet:   state.Table.HighBet,
+		Pot:       state.Table.Pot,
+		Hand:      me.Cards,
+		Community: state.Table.CommunityCards,
+		Phase:     state.Table.RoundPhase,
+		CanRaise:  canRaise,
+		Retry:     retry,
+	}
+
+	action := b.strategy.Decide(view)
+	if action.Action == unet.CodeBet && state.Table.HighBet > 0 && !view.CanRaise {
+		action = EvtGameAction{Action: unet.CodeCall}
+	}
+	if action.Action == unet.CodeBet {
+		// strategies don't know the min raise, they get pulled up to it
+		amount, _ := strconv.Atoi(action.Amount)
+		minTo, maxTo := betLimits(state.Table, me)
+		amount = min(max(amount, minTo), maxTo)
+		netStr, ok := unet.WriteVarInt(amount)
+		if !ok {
+			action = EvtGameAction{Action: unet.CodeCall}
+		} else {
+			action.Amount = netStr
+		}
+	}
+
+	// the client refuses a call with nothing to call, that would stall the turn
+	if action.Action == unet.CodeCall && state.Table.HighBet == 0 {
+		action = EvtGameAction{Action: unet.CodeCheck}
+	}
+
+	b.acted = true
+	b.lastAction = time.Now()
+	fmt.Printf("Bot %s: %s %s\n", state.Nickname, action.Action, action.Amount)
+	return action
+}
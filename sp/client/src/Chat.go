@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	unet "poker-client/ups_net"
+	w "poker-client/window"
+)
+
+// older lines are dropped, the log is only for the lobby or room you're in
+const maxChatLines = 100
+
+type ChatLine struct {
+	Time time.Time
+	Nick string // empty for notices from the client itself
+	Text string
+}
+
+// ChatLog is what was said since entering the lobby or the current room
+type ChatLog struct {
+	Lines []ChatLine
+	Input string
+
+	limiter unet.ChatLimiter
+}
+
+func (c *ChatLog) Add(nick, text string) {
+	c.Lines = append(c.Lines, ChatLine{Time: time.Now(), Nick: nick, Text: text})
+	if len(c.Lines) > maxChatLines {
+		c.Lines = c.Lines[len(c.Lines)-maxChatLines:]
+	}
+}
+
+func (c *ChatLog) Notice(format string, args ...any) {
+	c.Add("", fmt.Sprintf(format, args...))
+}
+
+// Clear keeps the limiter, leaving a room doesn't reset the rate limit
+func (c *ChatLog) Clear() {
+	c.Lines = nil
+	c.Input = ""
+}
+
+// sendChat checks the same limits as the server, so the player is told why
+// instead of just getting a CHFL. The message shows up once the server echoes it.
+func sendChat(ctx *ProgCtx, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	if !ctx.NetHandler.HasCapability(unet.CapChat) {
+		ctx.Popup.AddPopup("This server has no chat", 2*time.Second)
+		return
+	}
+
+	ctx.StateMutex.Lock()
+	fits := unet.ChatFits(ctx.State.Nickname, text)
+	allowed := fits && ctx.State.Chat.limiter.Allow(time.Now())
+	ctx.StateMutex.Unlock()
+
+	if !fits {
+		ctx.Popup.AddPopup("Message is too long", 2*time.Second)
+		return
+	}
+
+	if !allowed {
+		ctx.Popup.AddPopup("You're sending messages too fast", 2*time.Second)
+		return
+	}
+
+	ctx.NetHandler.SendMsg(&unet.ChatMsg{Text: text})
+}
+
+// handleChatMessage applies CHMS and CHFL, the caller holds the state lock
+func handleChatMessage(ctx *ProgCtx, msg unet.Message) bool {
+	switch m := msg.(type) {
+	case *unet.ChatMessageMsg:
+		ctx.State.Chat.Add(m.Nick, m.Text)
+	case *unet.ChatFailMsg:
+		ctx.State.Chat.Notice("The server didn't accept your message")
+	default:
+		return false
+	}
+
+	ctx.UI.SetDirty()
+	return true
+}
+
+func buildChatComponent(chat *ChatLog) *w.ChatComponent {
+	comp := w.NewChatComponent("Chat_Send", &chat.Input, 9999)
+	for _, line := range chat.Lines {
+		if line.Nick == "" {
+			comp.AddNotice(line.Text)
+			continue
+		}
+		comp.AddLine(line.Nick, line.Text)
+	}
+	return comp
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	unet "poker-client/ups_net"
+)
+
+// Config is read from a JSON file, then POKER_* env vars and flags override
+// single values. Only the last server, nick and the server list are ever
+// written back.
+type Config struct {
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Nick     string `json:"nick"` // empty picks a random one
+	Chips    int    `json:"chips"`
+	HandsDir string `json:"hands_dir"` // empty turns the hand files off
+
+	WindowWidth  int32 `json:"window_width"`
+	WindowHeight int32 `json:"window_height"`
+
+	ReconnectMax      int      `json:"reconnect_max"`
+	ReconnectInterval Duration `json:"reconnect_interval"`
+	AliveInterval     Duration `json:"alive_interval"`
+
+	Hotkeys Hotkeys `json:"hotkeys"`
+
+	Servers []SavedServer `json:"servers"`
+}
+
+// Duration is written as "1.5s" instead of nanoseconds
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(str)
+	*d = Duration(parsed)
+	return err
+}
+
+func defaultConfig() Config {
+	net := unet.DefaultNetConfig()
+	return Config{
+		Host:              "127.0.0.1",
+		Port:              "8080",
+		Chips:             1000,
+		HandsDir:          "hands",
+		WindowWidth:       1600,
+		WindowHeight:      1000,
+		ReconnectMax:      net.ReconnectMax,
+		ReconnectInterval: Duration(net.ReconnectInterval),
+		AliveInterval:     Duration(net.AliveInterval),
+		Hotkeys:           defaultHotkeys(),
+	}
+}
+
+func (c Config) NetConfig() unet.NetConfig {
+	return unet.NetConfig{
+		ReconnectMax:      c.ReconnectMax,
+		ReconnectInterval: time.Duration(c.ReconnectInterval),
+		AliveInterval:     time.Duration(c.AliveInterval),
+	}
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("POKER_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "poker-client.json"
+	}
+	return filepath.Join(dir, "poker-client", "config.json")
+}
+
+// readConfigFile fills cfg from path, a missing file leaves it untouched
+func readConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// configSetting is one value that env vars and flags can override
+type configSetting struct {
+	name  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+var configSettings = []configSetting{
+	{"host", "server address", func(c *Config, v string) error { c.Host = v; return nil }},
+	{"port", "server port", func(c *Config, v string) error { c.Port = v; return nil }},
+	{"nick", "nickname, bots are numbered when there is more than one", func(c *Config, v string) error { c.Nick = v; return nil }},
+	{"chips", "chips brought to the table", func(c *Config, v string) error { return setInt(&c.Chips, v) }},
+	{"hands", "directory for hand history files, empty to disable", func(c *Config, v string) error { c.HandsDir = v; return nil }},
+	{"width", "window width", func(c *Config, v string) error { return setInt32(&c.WindowWidth, v) }},
+	{"height", "window height", func(c *Config, v string) error { return setInt32(&c.WindowHeight, v) }},
+	{"reconnect-max", "reconnect attempts before giving up", func(c *Config, v string) error { return setInt(&c.ReconnectMax, v) }},
+	{"reconnect-interval", "wait between reconnect attempts, eg. 1s", func(c *Config, v string) error { return setDuration(&c.ReconnectInterval, v) }},
+	{"alive-interval", "keepalive period, eg. 10s", func(c *Config, v string) error { return setDuration(&c.AliveInterval, v) }},
+	{"key-fold", "fold hotkey", func(c *Config, v string) error { c.Hotkeys.Fold = v; return nil }},
+	{"key-call", "check/call hotkey", func(c *Config, v string) error { c.Hotkeys.Call = v; return nil }},
+	{"key-bet", "hotkey to focus the bet amount", func(c *Config, v string) error { c.Hotkeys.Bet = v; return nil }},
+	{"key-ready", "ready hotkey", func(c *Config, v string) error { c.Hotkeys.Ready = v; return nil }},
+}
+
+func setInt(dst *int, v string) error {
+	num, err := strconv.Atoi(strings.TrimSpace(v))
+	if err == nil {
+		*dst = num
+	}
+	return err
+}
+
+func setInt32(dst *int32, v string) error {
+	num, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+	if err == nil {
+		*dst = int32(num)
+	}
+	return err
+}
+
+func setDuration(dst *Duration, v string) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(v))
+	if err == nil {
+		*dst = Duration(parsed)
+	}
+	return err
+}
+
+// envName is POKER_ and the flag name, eg. POKER_RECONNECT_MAX
+func (s configSetting) envName() string {
+	return "POKER_" + strings.ToUpper(strings.ReplaceAll(s.name, "-", "_"))
+}
+
+// configFlags registers a flag per setting. The values are only kept, they
+// are applied after the file and the environment.
+func configFlags(flags *flag.FlagSet) map[string]string {
+	values := make(map[string]string)
+	for _, setting := range configSettings {
+		flags.Func(setting.name, setting.usage+" (env "+setting.envName()+")", func(v string) error {
+			values[setting.name] = v
+			return nil
+		})
+	}
+	return values
+}
+
+// applyOverrides applies the environment, then the flags that were set
+func applyOverrides(cfg *Config, flagValues map[string]string) error {
+	for _, setting := range configSettings {
+		if v, ok := os.LookupEnv(setting.envName()); ok {
+			if err := setting.set(cfg, v); err != nil {
+				return fmt.Errorf("%s: %w", setting.envName(), err)
+			}
+		}
+	}
+
+	for _, setting := range configSettings {
+		if v, ok := flagValues[setting.name]; ok {
+			if err := setting.set(cfg, v); err != nil {
+				return fmt.Errorf("-%s: %w", setting.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.WindowWidth <= 0 || c.WindowHeight <= 0:
+		return fmt.Errorf("window size must be positive")
+	case c.ReconnectMax < 0:
+		return fmt.Errorf("reconnect_max can't be negative")
+	case c.ReconnectInterval <= 0 || c.AliveInterval <= 0:
+		return fmt.Errorf("intervals must be positive")
+	}
+
+	if _, err := c.Hotkeys.bindings(); err != nil {
+		return fmt.Errorf("hotkeys: %w", err)
+	}
+	return nil
+}
+
+// updateConfigFile changes what's in the file and keeps the rest as it was
+// there, values from env vars and flags stay out of it
+func updateConfigFile(path string, update func(cfg *Config)) error {
+	cfg := defaultConfig()
+	if err := readConfigFile(path, &cfg); err != nil {
+		return err
+	}
+
+	update(&cfg)
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// rememberServer is called once a server took the nick
+func rememberServer(ctx *ProgCtx) {
+	if ctx.ConfigPath == "" {
+		return
+	}
+
+	ctx.StateMutex.RLock()
+	host, port, nick := ctx.State.ServerIP, ctx.State.ServerPort, ctx.State.Nickname
+	ctx.StateMutex.RUnlock()
+
+	err := updateConfigFile(ctx.ConfigPath, func(cfg *Config) {
+		cfg.Host = host
+		cfg.Port = port
+		cfg.Nick = nick
+	})
+	if err != nil {
+		fmt.Println("Failed to save the last server:", err)
+	}
+}
@@ -15,6 +15,7 @@ const (
 	ScreenWaitingForRooms
 	ScreenRoomSelect
 	ScreenInGame
+	ScreenServers
 )
 
 type Room struct {
@@ -38,6 +39,8 @@ type PlayerData struct {
 	IsMyTurn     bool
 	IsFolded     bool
 	IsReady      bool
+	IsAllIn      bool
+	HasLeft      bool // left mid-hand, kept until GMDN since their chips stay in the pots
 	ActionTaken  string
 	ActionAmount int
 }
@@ -46,8 +49,12 @@ type PokerTable struct {
 	Players        map[string]PlayerData
 	CommunityCards []Card
 
-	Pot     int
-	HighBet int
+	Pot       int
+	HighBet   int
+	LastRaise int // size of the last full bet or raise this round
+
+	Seats  []string // nick by seat index as the server has them, "" is a free seat
+	Dealer int      // seat index of the button, -1 if the server doesn't tell
 
 	RoundPhase string // "PreFlop", "Flop", "Turn", "River"
 }
@@ -64,9 +71,19 @@ type GameState struct {
 	Nickname   string
 	ChipsStr   string
 
-	Table     PokerTable
-	BetAmount string
-	Showdown  bool
+	Servers     []SavedServer
+	ServerForm  ServerForm
+	ServerPings map[string]string // last probe result per address
+
+	RoomID     int
+	Spectating bool // watching RoomID without a seat, there is no "me" at the table
+	Table      PokerTable
+	BetAmount  string
+	BetSize    int // the bet slider, follows BetAmount while it holds a number
+	Showdown   bool
+	Results    ShowdownResults
+	History    HandHistory
+	Chat       ChatLog
 }
 
 type UserInputEvent any
@@ -92,6 +109,30 @@ type EvtRoomJoin struct {
 	RoomID string
 }
 
+type EvtRoomSpectate struct {
+	RoomID string
+}
+
+type EvtChat struct {
+	Text string
+}
+
+type EvtOpenServers struct{}
+type EvtServerSave struct{}
+type EvtServerNew struct{}
+
+type EvtServerEdit struct {
+	Index int
+}
+
+type EvtServerDelete struct {
+	Index int
+}
+
+type EvtServerPing struct {
+	Index int // -1 pings all of them
+}
+
 type UIElement struct {
 	dirty     bool
 	component w.RGComponent
@@ -103,6 +144,7 @@ type UIStore struct {
 	Reconnecting UIElement
 	RoomSelect   UIElement
 	Game         UIElement
+	Servers      UIElement
 }
 
 func (store *UIStore) SetDirty() {
@@ -124,4 +166,8 @@ type ProgCtx struct {
 
 	UI    UIStore
 	Popup PopupManager
+	Hands HandRecorder
+
+	ConfigPath string // the last server is saved here, empty for bots
+	Keys       keyBindings
 }
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HandRecorder writes every hand played from GMST to GMDN into a text file,
+// the layout follows PokerStars so the usual review tools can read it
+type HandRecorder struct {
+	Dir string // empty turns the recorder off
+
+	active bool
+	number int // hands started this session
+	id     string
+	lines  []string
+
+	seats  []string
+	street string
+	board  []Card
+	dealt  int
+
+	folded map[string]string // street the player folded on
+	shown  map[string]string
+	won    map[string]int
+}
+
+// cardCode is the two letter notation, eg. "Th" for 10 of Hearts
+func cardCode(id int) string {
+	if id < 0 || id > 51 {
+		return "??"
+	}
+	return string("23456789TJQKA"[id%13]) + string("hdcs"[id/13])
+}
+
+func cardCodes(cards []Card) string {
+	codes := make([]string, 0, len(cards))
+	for _, card := range cards {
+		codes = append(codes, cardCode(card.ID))
+	}
+	return "[" + strings.Join(codes, " ") + "]"
+}
+
+// fileSafe keeps a nick from naming anything outside the history directory
+func fileSafe(nick string) string {
+	safe := strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+			return c
+		}
+		return '_'
+	}, nick)
+
+	if strings.Trim(safe, "_") == "" {
+		return "player"
+	}
+	return safe
+}
+
+func (r *HandRecorder) add(format string, args ...any) {
+	if r.active {
+		r.lines = append(r.lines, fmt.Sprintf(format, args...))
+	}
+}
+
+// Start begins a new hand, hands joined midway aren't recorded
+func (r *HandRecorder) Start(table *PokerTable, tableName string, now time.Time) {
+	r.active = r.Dir != ""
+	r.number++
+	// the numbering starts over with every run but the file is shared by the
+	// day, so the start time makes the hand ID unique
+	r.id = fmt.Sprintf("%d%03d", now.Unix(), r.number%1000)
+	r.lines = nil
+	r.street = "PreFlop"
+	r.board = nil
+	r.dealt = 0
+	r.folded = make(map[string]string)
+	r.shown = make(map[string]string)
+	r.won = make(map[string]int)
+
+	// the protocol has no seat numbers, the order just has to stay the same within a hand
+	r.seats = make([]string, 0, len(table.Players))
+	for name := range table.Players {
+		r.seats = append(r.seats, name)
+	}
+	slices.Sort(r.seats)
+
+	r.add("Hand #%s: Hold'em No Limit - %s", r.id, now.Format("2006/01/02 15:04:05"))
+	r.add("Table '%s' %d-max", tableName, len(r.seats))
+	for i, name := range r.seats {
+		r.add("Seat %d: %s (%d in chips)", i+1, name, table.Players[name].ChipCount)
+	}
+	r.add("*** HOLE CARDS ***")
+}
+
+func (r *HandRecorder) Dealt(nick string, cards []Card) {
+	r.add("Dealt to %s %s", nick, cardCodes(cards))
+}
+
+// Board writes the street headers the board has reached since the last call,
+// all-in runouts deal several cards without a betting round in between
+func (r *HandRecorder) Board(board []Card) {
+	r.board = slices.Clone(board)
+	for ; r.dealt < len(board); r.dealt++ {
+		switch r.dealt + 1 {
+		case 3:
+			r.street = "Flop"
+			r.add("*** FLOP *** %s", cardCodes(board[:3]))
+		case 4:
+			r.street = "Turn"
+			r.add("*** TURN *** %s %s", cardCodes(board[:3]), cardCodes(board[3:4]))
+		case 5:
+			r.street = "River"
+			r.add("*** RIVER *** %s %s", cardCodes(board[:4]), cardCodes(board[4:5]))
+		}
+	}
+}
+
+func (r *HandRecorder) Action(nick, action string, amount int) {
+	switch action {
+	case "FOLD":
+		r.folded[nick] = r.street
+	case "LEFT":
+		r.folded[nick] = r.street
+		r.add("%s leaves the table", nick)
+		return
+	}
+	r.add("%s: %s", nick, describeAction(action, amount))
+}
+
+func (r *HandRecorder) TimedOut(nick string) {
+	r.add("%s has timed out", nick)
+}
+
+// Showdown expects the revealed cards to be on the table already
+func (r *HandRecorder) Showdown(table *PokerTable) {
+	r.Board(table.CommunityCards)
+	r.add("*** SHOW DOWN ***")
+	for _, name := range r.seats {
+		player, exists := table.Players[name]
+		if !exists || player.IsFolded || len(player.Cards) != 2 || player.Cards[0].Hidden {
+			continue
+		}
+
+		shown := cardCodes(player.Cards)
+		if desc := describeHand(player.Cards, table.CommunityCards); desc != "" {
+			shown += " (" + desc + ")"
+		}
+		r.shown[name] = shown
+		r.add("%s: shows %s", name, shown)
+	}
+}
+
+func (r *HandRecorder) Collected(nick string, amount int) {
+	r.won[nick] += amount
+	r.add("%s collected %d from pot", nick, amount)
+}
+
+func (r *HandRecorder) NobodyWon() {
+	r.add("Nobody collected the pot")
+}
+
+// Finish adds the summary and appends the hand to the players file
+func (r *HandRecorder) Finish(me string, pot int) error {
+	if !r.active {
+		return nil
+	}
+
+	r.add("*** SUMMARY ***")
+	summary := fmt.Sprintf("Total pot %d", pot)
+	if len(r.board) > 0 {
+		summary += " | Board " + cardCodes(r.board)
+	}
+	r.add("%s", summary)
+
+	for i, name := range r.seats {
+		seat := fmt.Sprintf("Seat %d: %s", i+1, name)
+		shown, showed := r.shown[name]
+		won, collected := r.won[name]
+
+		switch street, folded := r.folded[name]; {
+		case folded && street == "PreFlop":
+			r.add("%s folded before Flop", seat)
+		case folded:
+			r.add("%s folded on the %s", seat, street)
+		case showed && collected:
+			r.add("%s showed %s and won (%d)", seat, shown, won)
+		case showed:
+			r.add("%s showed %s and lost", seat, shown)
+		case collected:
+			r.add("%s collected (%d)", seat, won)
+		default:
+			r.add("%s mucked", seat)
+		}
+	}
+
+	r.active = false
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(r.Dir, fmt.Sprintf("%s_%s.txt", fileSafe(me), time.Now().Format("20060102")))
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// hands are separated by blank lines like in the original format
+	_, err = file.WriteString(strings.Join(r.lines, "\n") + "\n\n\n")
+	return err
+}
@@ -1,6 +1,11 @@
 package main
 
-import "math"
+import (
+	"math"
+	"slices"
+
+	"poker-client/poker"
+)
 
 func actionIntToString(action int) string {
 	switch action {
@@ -33,3 +38,28 @@ func countDigits(n int) int {
 	}
 	return digitCount
 }
+
+// evaluateCards scores the visible cards, ok is false while there are fewer than 5
+func evaluateCards(hand []Card, community []Card) (poker.Hand, bool) {
+	ids := make([]int, 0, len(hand)+len(community))
+	for _, card := range slices.Concat(hand, community) {
+		if card.Hidden {
+			return poker.Hand{}, false
+		}
+		ids = append(ids, card.ID)
+	}
+
+	score, err := poker.Evaluate(ids)
+	if err != nil {
+		return poker.Hand{}, false
+	}
+	return score, true
+}
+
+func describeHand(hand []Card, community []Card) string {
+	score, ok := evaluateCards(hand, community)
+	if !ok {
+		return ""
+	}
+	return score.Describe()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	w "poker-client/window"
+)
+
+// older hands are dropped, the panel is for the current session only
+const maxHistoryHands = 20
+
+type HistoryEntry struct {
+	Time time.Time
+	Text string
+}
+
+type HistoryRound struct {
+	Name    string
+	Entries []HistoryEntry
+}
+
+type HistoryHand struct {
+	Number  int
+	Started time.Time
+	Rounds  []HistoryRound
+}
+
+// HandHistory is what happened at the table, grouped per hand and betting round
+type HandHistory struct {
+	Hands  []HistoryHand
+	played int
+}
+
+func (h *HandHistory) StartHand(now time.Time) {
+	h.played++
+	h.Hands = append(h.Hands, HistoryHand{Number: h.played, Started: now})
+	if len(h.Hands) > maxHistoryHands {
+		h.Hands = h.Hands[len(h.Hands)-maxHistoryHands:]
+	}
+}
+
+func (h *HandHistory) StartRound(name string) {
+	if len(h.Hands) == 0 {
+		h.StartHand(time.Now())
+	}
+
+	hand := &h.Hands[len(h.Hands)-1]
+	hand.Rounds = append(hand.Rounds, HistoryRound{Name: name})
+}
+
+// Add records to the current round, joining mid hand starts one on the spot
+func (h *HandHistory) Add(format string, args ...any) {
+	if len(h.Hands) == 0 || len(h.Hands[len(h.Hands)-1].Rounds) == 0 {
+		h.StartRound("In progress")
+	}
+
+	hand := &h.Hands[len(h.Hands)-1]
+	round := &hand.Rounds[len(hand.Rounds)-1]
+	round.Entries = append(round.Entries, HistoryEntry{Time: time.Now(), Text: fmt.Sprintf(format, args...)})
+}
+
+// roundName follows the board, GMRD doesn't say which round it starts
+func roundName(communityCards int) string {
+	switch communityCards {
+	case 0:
+		return "PreFlop"
+	case 3:
+		return "Flop"
+	case 4:
+		return "Turn"
+	default:
+		return "River"
+	}
+}
+
+// describeAction turns a PACT into something readable, eg. "bets 50"
+func describeAction(action string, amount int) string {
+	switch action {
+	case "CHCK":
+		return "checks"
+	case "CALL":
+		return fmt.Sprintf("calls %d", amount)
+	case "FOLD":
+		return "folds"
+	case "BETT":
+		return fmt.Sprintf("bets %d", amount)
+	case "LEFT":
+		return "left the table"
+	}
+	return fmt.Sprintf("%s %d", action, amount)
+}
+
+// describeRaise takes the round bet the raise went to, PACT only has the chips put in
+func describeRaise(to int) string {
+	return fmt.Sprintf("raises to %d", to)
+}
+
+func buildHistoryComponent(history *HandHistory) *w.HistoryComponent {
+	comp := w.NewHistoryComponent()
+	for _, hand := range history.Hands {
+		comp.AddLine(fmt.Sprintf("Hand #%d  %s", hand.Number, hand.Started.Format(time.TimeOnly)), 0)
+		for _, round := range hand.Rounds {
+			comp.AddLine(round.Name, 1)
+			for _, entry := range round.Entries {
+				comp.AddLine(entry.Time.Format(time.TimeOnly)+"  "+entry.Text, 2)
+			}
+		}
+	}
+	return comp
+}
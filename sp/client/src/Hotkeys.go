@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	w "poker-client/window"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Hotkeys are the in-game shortcuts by key name: a letter, a digit, F1-F12 or SPACE
+type Hotkeys struct {
+	Fold  string `json:"fold"`
+	Call  string `json:"call"` // check when there is nothing to call
+	Bet   string `json:"bet"`  // focuses the bet box
+	Ready string `json:"ready"`
+}
+
+func defaultHotkeys() Hotkeys {
+	return Hotkeys{Fold: "F", Call: "C", Bet: "B", Ready: "R"}
+}
+
+// keyBindings are the parsed Hotkeys
+type keyBindings struct {
+	fold, call, bet, ready int32
+}
+
+func parseKey(name string) (int32, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+
+	switch {
+	case name == "SPACE":
+		return rl.KeySpace, nil
+	case len(name) == 1 && (name[0] >= 'A' && name[0] <= 'Z' || name[0] >= '0' && name[0] <= '9'):
+		// raylib uses the ascii codes for these
+		return int32(name[0]), nil
+	case len(name) > 1 && name[0] == 'F':
+		if num, err := strconv.Atoi(name[1:]); err == nil && num >= 1 && num <= 12 {
+			return rl.KeyF1 + int32(num-1), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown key %q", name)
+}
+
+func (h Hotkeys) bindings() (keyBindings, error) {
+	var keys keyBindings
+	for _, bind := range []struct {
+		dst  *int32
+		name string
+	}{{&keys.fold, h.Fold}, {&keys.call, h.Call}, {&keys.bet, h.Bet}, {&keys.ready, h.Ready}} {
+		key, err := parseKey(bind.name)
+		if err != nil {
+			return keys, err
+		}
+		*bind.dst = key
+	}
+
+	return keys, nil
+}
+
+// handleHotkeys runs after the game screen was drawn, so a hotkey only
+// works when its button is on the screen
+func handleHotkeys(keys keyBindings, eventChannel chan<- w.UIEvent) {
+	if w.Focus.Typing() {
+		return
+	}
+
+	click := func(id string) {
+		if w.Focus.Has(id) {
+			eventChannel <- w.UIEvent{SourceID: id, Type: w.EventClick}
+		}
+	}
+
+	switch {
+	case rl.IsKeyPressed(keys.fold):
+		click("Game_Fold")
+	case rl.IsKeyPressed(keys.call):
+		click("Game_Check")
+		click("Game_Call")
+	case rl.IsKeyPressed(keys.bet):
+		if w.Focus.Has("Game_BetAmount") {
+			w.Focus.Set("Game_BetAmount")
+		}
+	case rl.IsKeyPressed(keys.ready):
+		click("Game_Ready")
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"cmp"
+	"flag"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,7 +18,38 @@ import (
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-func initProgCtx() *ProgCtx {
+// netOptions decide what the network thread talks to
+type netOptions struct {
+	capture     string // file for the wire traffic, empty for none
+	replay      string // capture to play back instead of connecting
+	replaySpeed float64
+}
+
+// startNetwork starts the network thread, against a real server or a replayed capture
+func startNetwork(ctx *ProgCtx, opts netOptions) error {
+	if opts.capture != "" {
+		capture, err := unet.NewCapture(opts.capture)
+		if err != nil {
+			return err
+		}
+		ctx.NetHandler.SetCapture(capture)
+	}
+
+	if opts.replay != "" {
+		entries, err := unet.LoadCapture(opts.replay)
+		if err != nil {
+			return err
+		}
+
+		go ctx.NetHandler.RunReplay(entries, opts.replaySpeed)
+		return nil
+	}
+
+	go ctx.NetHandler.Run()
+	return nil
+}
+
+func initProgCtx(cfg Config) *ProgCtx {
 	ctx := ProgCtx{}
 	seededSource := rand.NewSource(time.Now().UnixNano())
 	r := rand.New(seededSource)
@@ -25,56 +60,100 @@ func initProgCtx() *ProgCtx {
 	ctx.State.Screen = ScreenMainMenu
 	ctx.State.Rooms = make(map[int]Room)
 	ctx.State.Table.Players = make(map[string]PlayerData)
+	ctx.State.Table.Dealer = -1
 	ctx.StateMutex = sync.RWMutex{}
-	ctx.State.ServerIP = "127.0.0.1"
-	ctx.State.ServerPort = "8080"
-	ctx.State.Nickname = "Client" + fmt.Sprintf("%d", r.Intn(100))
-	ctx.State.ChipsStr = fmt.Sprintf("%d", rand.Intn(1_000_000_000))
+	ctx.State.ServerIP = cfg.Host
+	ctx.State.ServerPort = cfg.Port
+	ctx.State.Nickname = cmp.Or(cfg.Nick, "Client"+fmt.Sprintf("%d", r.Intn(100)))
+	ctx.State.ChipsStr = fmt.Sprintf("%d", cfg.Chips)
 	ctx.State.BetAmount = ""
+	ctx.State.RoomID = -1
+	ctx.State.Servers = cfg.Servers
+	ctx.State.ServerForm.Reset()
+	ctx.State.ServerPings = make(map[string]string)
+	ctx.Hands.Dir = cfg.HandsDir
+	ctx.Keys, _ = cfg.Hotkeys.bindings() // checked by validate
 
 	ctx.NetHandler = unet.NetHandler{}
 	ctx.NetHandler.Init()
-	go ctx.NetHandler.Run()
+	ctx.NetHandler.SetConfig(cfg.NetConfig())
 
 	ctx.EventChan = ctx.NetHandler.EventChan()
 
 	ctx.Popup = NewPopupManager()
 
-	buildUI(&ctx)
-
 	return &ctx
 }
 
+// connectFromMenu checks the nick and chips from the main menu and connects
+// to ServerIP:ServerPort, the server list fills those in before calling it
+func connectFromMenu(ctx *ProgCtx) {
+	if len(ctx.State.Nickname) <= 0 || len(ctx.State.Nickname) > 9999 {
+		ctx.Popup.AddPopup("Please enter a nick within the length limits", time.Second*3)
+		return
+	}
+
+	if len(ctx.State.ChipsStr) <= 0 || len(ctx.State.ChipsStr) > 100 {
+		ctx.Popup.AddPopup("Please enter chip value within the length limits", time.Second*3)
+		return
+	}
+
+	amount, err := strconv.Atoi(strings.TrimSpace(ctx.State.ChipsStr))
+	if err != nil {
+		ctx.Popup.AddPopup("Please enter a numeric chip value", time.Second*3)
+		return
+	}
+
+	_, ok := unet.WriteVarInt(amount)
+	if !ok {
+		ctx.Popup.AddPopup("Invalid value, please enter a different one", time.Second*3)
+		return
+	}
+
+	ctx.State.Table.Players[ctx.State.Nickname] = PlayerData{
+		ChipCount: amount,
+	}
+
+	ctx.UserInputChan <- EvtConnect{Host: ctx.State.ServerIP, Port: ctx.State.ServerPort}
+}
+
+// connectToSaved connects to an entry of the server list with the nick and
+// chips from the main menu
+func connectToSaved(ctx *ProgCtx, index string) {
+	idx, _ := strconv.Atoi(index)
+
+	ctx.StateMutex.Lock()
+	if idx < 0 || idx >= len(ctx.State.Servers) {
+		ctx.StateMutex.Unlock()
+		return
+	}
+	server := ctx.State.Servers[idx]
+	ctx.State.ServerIP = server.Host
+	ctx.State.ServerPort = server.Port
+	ctx.StateMutex.Unlock()
+
+	connectFromMenu(ctx)
+}
+
 func handleUIEvent(ctx *ProgCtx, event w.UIEvent) {
 	switch event.SourceID {
 	case "MainMenu_ConnectBtn":
-		if len(ctx.State.Nickname) <= 0 || len(ctx.State.Nickname) > 9999 {
-			ctx.Popup.AddPopup("Please enter a nick within the length limits", time.Second*3)
-			return
-		}
+		connectFromMenu(ctx)
 
-		if len(ctx.State.ChipsStr) <= 0 || len(ctx.State.ChipsStr) > 100 {
-			ctx.Popup.AddPopup("Please enter chip value within the length limits", time.Second*3)
-			return
-		}
+	case "MainMenu_ServersBtn":
+		ctx.UserInputChan <- EvtOpenServers{}
 
-		amount, err := strconv.Atoi(strings.TrimSpace(ctx.State.ChipsStr))
-		if err != nil {
-			ctx.Popup.AddPopup("Please enter a numeric chip value", time.Second*3)
-			return
-		}
+	case "Servers_SaveBtn":
+		ctx.UserInputChan <- EvtServerSave{}
 
-		_, ok := unet.WriteVarInt(amount)
-		if !ok {
-			ctx.Popup.AddPopup("Invalid value, please enter a different one", time.Second*3)
-			return
-		}
+	case "Servers_NewBtn":
+		ctx.UserInputChan <- EvtServerNew{}
 
-		ctx.State.Table.Players[ctx.State.Nickname] = PlayerData{
-			ChipCount: amount,
-		}
+	case "Servers_PingAllBtn":
+		ctx.UserInputChan <- EvtServerPing{Index: -1}
 
-		ctx.UserInputChan <- EvtConnect{Host: ctx.State.ServerIP, Port: ctx.State.ServerPort}
+	case "Servers_BackBtn":
+		ctx.UserInputChan <- EvtBackToMain{}
 
 	case "MainMenu_CloseBtn":
 		ctx.UserInputChan <- EvtQuit{}
@@ -107,8 +186,6 @@ func handleUIEvent(ctx *ProgCtx, event w.UIEvent) {
 	case "Game_Bet":
 		ctx.StateMutex.RLock()
 		betStr := strings.TrimSpace(ctx.State.BetAmount)
-		myNickname := ctx.State.Nickname
-		table := ctx.State.Table
 		ctx.StateMutex.RUnlock()
 
 		if betStr == "" {
@@ -122,17 +199,7 @@ func handleUIEvent(ctx *ProgCtx, event w.UIEvent) {
 			return
 		}
 
-		myData, exists := table.Players[myNickname]
-		if !exists {
-			ctx.Popup.AddPopup("Error: Player data not found", time.Second*3)
-			return
-		}
-
-		if amount > myData.ChipCount {
-			ctx.Popup.AddPopup(fmt.Sprintf("You only have %d chips", myData.ChipCount), time.Second*3)
-			return
-		}
-
+		// limits are checked by validateGameAction
 		netStr, ok := unet.WriteVarInt(amount)
 		if !ok {
 			ctx.Popup.AddPopup("Bet amount is invalid", time.Second*2)
@@ -145,6 +212,11 @@ func handleUIEvent(ctx *ProgCtx, event w.UIEvent) {
 
 		ctx.UserInputChan <- EvtGameAction{Action: "BETT", Amount: netStr}
 
+	case "Game_BetSlider":
+		ctx.StateMutex.Lock()
+		ctx.State.BetAmount = strconv.Itoa(ctx.State.BetSize)
+		ctx.StateMutex.Unlock()
+
 	case "Game_Ready":
 		ctx.UserInputChan <- EvtGameAction{Action: "RDY1"}
 
@@ -163,15 +235,123 @@ func handleUIEvent(ctx *ProgCtx, event w.UIEvent) {
 	case "Game_ShowOK":
 		ctx.UserInputChan <- EvtGameAction{Action: "SDOK"}
 
+	case "Chat_Send":
+		ctx.StateMutex.Lock()
+		text := ctx.State.Chat.Input
+		ctx.State.Chat.Input = ""
+		ctx.StateMutex.Unlock()
+
+		ctx.UserInputChan <- EvtChat{Text: text}
+
 	default:
-		after, found := strings.CutPrefix(event.SourceID, "join_")
-		if found {
+		if preset, found := findBetPreset(event.SourceID); found {
+			applyBetPreset(ctx, preset)
+		}
+		if after, found := strings.CutPrefix(event.SourceID, "join_"); found {
 			ctx.UserInputChan <- EvtRoomJoin{RoomID: after}
 		}
+		if after, found := strings.CutPrefix(event.SourceID, "spectate_"); found {
+			ctx.UserInputChan <- EvtRoomSpectate{RoomID: after}
+		}
+		if after, found := strings.CutPrefix(event.SourceID, "server_connect_"); found {
+			connectToSaved(ctx, after)
+		}
+		if after, found := strings.CutPrefix(event.SourceID, "server_ping_"); found {
+			idx, _ := strconv.Atoi(after)
+			ctx.UserInputChan <- EvtServerPing{Index: idx}
+		}
+		if after, found := strings.CutPrefix(event.SourceID, "server_edit_"); found {
+			idx, _ := strconv.Atoi(after)
+			ctx.UserInputChan <- EvtServerEdit{Index: idx}
+		}
+		if after, found := strings.CutPrefix(event.SourceID, "server_delete_"); found {
+			idx, _ := strconv.Atoi(after)
+			ctx.UserInputChan <- EvtServerDelete{Index: idx}
+		}
 	}
 }
 
+// runHeadless plays with count bots until all of them quit, no window is opened
+func runHeadless(count int, strategyName string, roomID int, cfg Config, opts netOptions) {
+	newStrategy, ok := strategies[strategyName]
+	if !ok {
+		fmt.Println("Unknown strategy", strategyName, "- use one of", strings.Join(strategyNames(), ", "))
+		os.Exit(1)
+	}
+
+	contexts := make([]*ProgCtx, 0, count)
+	for i := range count {
+		ctx := initProgCtx(cfg)
+		if count > 1 {
+			ctx.State.Nickname = fmt.Sprintf("%s_%d", cmp.Or(cfg.Nick, "Bot"), i+1)
+		}
+
+		// one capture per bot, they would interleave otherwise
+		botOpts := opts
+		if count > 1 && opts.capture != "" {
+			ext := filepath.Ext(opts.capture)
+			botOpts.capture = strings.TrimSuffix(opts.capture, ext) + "_" + ctx.State.Nickname + ext
+		}
+		if err := startNetwork(ctx, botOpts); err != nil {
+			fmt.Println("Failed to start network:", err)
+			os.Exit(1)
+		}
+
+		r := rand.New(rand.NewSource(time.Now().UnixNano() + int64(i)))
+		bot := NewBot(newStrategy(r), roomID, cfg.Chips)
+
+		go gameThread(ctx)
+		go runBot(ctx, bot)
+
+		fmt.Printf("Bot %s started (%s)\n", ctx.State.Nickname, strategyName)
+		contexts = append(contexts, ctx)
+	}
+
+	for _, ctx := range contexts {
+		<-ctx.DoneChan
+	}
+	fmt.Println("Main: All bots stopped.")
+}
+
 func main() {
+	headless := flag.Bool("headless", false, "run bots instead of opening a window")
+	bots := flag.Int("bots", 1, "number of bots in headless mode")
+	strategy := flag.String("strategy", "passive", "bot strategy: "+strings.Join(strategyNames(), ", "))
+	room := flag.Int("room", -1, "room id to join, any free room if negative")
+	capture := flag.String("capture", "", "write all wire traffic to this file")
+	replay := flag.String("replay", "", "play a capture file back instead of connecting to a server")
+	replaySpeed := flag.Float64("replay-speed", 1, "replay speed multiplier, 0 plays everything at once")
+	configPath := flag.String("config", defaultConfigPath(), "config file, also where the last server is saved (env POKER_CONFIG)")
+	flagValues := configFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg := defaultConfig()
+	if err := readConfigFile(*configPath, &cfg); err != nil {
+		fmt.Println("Failed to read config:", err)
+		os.Exit(1)
+	}
+
+	// the saved nick belongs to the window client, a bot with it would take over its seat
+	if *headless {
+		cfg.Nick = ""
+	}
+
+	if err := applyOverrides(&cfg, flagValues); err != nil {
+		fmt.Println("Bad setting:", err)
+		os.Exit(1)
+	}
+	if err := cfg.validate(); err != nil {
+		fmt.Println("Bad config:", err)
+		os.Exit(1)
+	}
+
+	opts := netOptions{capture: *capture, replay: *replay, replaySpeed: *replaySpeed}
+
+	if *headless {
+		runHeadless(*bots, *strategy, *room, cfg, opts)
+		return
+	}
+
 	test_msg := make([]byte, 0)
 	msg_1 := []byte("PKRNGMST\n")
 	msg_2 := []byte("PKRPCDTP00043322\n")
@@ -194,17 +374,20 @@ func main() {
 
 	rl.SetConfigFlags(rl.FlagWindowResizable)
 
-	const (
-		screenWidth  int32 = 1600
-		screenHeight int32 = 1000
-	)
+	screenWidth, screenHeight := cfg.WindowWidth, cfg.WindowHeight
 
 	rl.InitWindow(screenWidth, screenHeight, "Poker Client")
 	defer rl.CloseWindow()
 
 	rl.SetTargetFPS(60)
 
-	ctx := initProgCtx()
+	ctx := initProgCtx(cfg)
+	ctx.ConfigPath = *configPath
+	if err := startNetwork(ctx, opts); err != nil {
+		fmt.Println("Failed to start network:", err)
+		return
+	}
+	buildUI(ctx)
 
 	// Start the "Game Thread"
 	go gameThread(ctx)
@@ -254,7 +437,15 @@ func main() {
 
 			elementsToDraw = append(elementsToDraw, ctx.UI.RoomSelect)
 
+		case ScreenServers:
+			servers := buildServerListUI(ctx)
+			servers.component.Rebuild(ctx.UI.Servers.component)
+			ctx.UI.Servers = servers
+
+			elementsToDraw = append(elementsToDraw, ctx.UI.Servers)
+
 		case ScreenInGame:
+			syncBetSize(ctx)
 			gameScreen := buildGameScreen(ctx)
 			gameScreen.component.Rebuild(ctx.UI.Game.component)
 			ctx.UI.Game = gameScreen
@@ -265,6 +456,8 @@ func main() {
 		// calculate popups everytime
 		ctx.Popup.Calculate(screenBounds)
 
+		w.Focus.Update()
+
 		rl.BeginDrawing()
 		rl.DrawFPS(0, 0)
 		rl.ClearBackground(rl.Black)
@@ -284,6 +477,10 @@ func main() {
 		ctx.Popup.Draw(uiEventChannel)
 		ctx.Popup.Update()
 
+		if currentScreen == ScreenInGame {
+			handleHotkeys(ctx.Keys, uiEventChannel)
+		}
+
 		elementsToDraw = elementsToDraw[:0]
 
 		rl.EndDrawing()
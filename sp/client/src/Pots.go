@@ -0,0 +1,74 @@
+package main
+
+import (
+	"slices"
+	"sort"
+)
+
+// TablePot is the main pot or one of the side pots
+type TablePot struct {
+	Amount   int
+	Eligible []string // players that can still win it, sorted by nick
+}
+
+// committed is everything the player put in this hand
+func (p PlayerData) committed() int {
+	return p.TotalBet + p.RoundBet
+}
+
+// putChips moves chips from the stack to the current round, the stack running dry means all-in
+func (p *PlayerData) putChips(amount int) {
+	p.ChipCount -= amount
+	p.RoundBet += amount
+	if p.ChipCount <= 0 && amount > 0 {
+		p.ChipCount = 0
+		p.IsAllIn = true
+	}
+}
+
+// computePots splits the chips committed this hand the same way the server does,
+// a pot for every level a player still in the hand got to. Players that left
+// keep their cards and can still win, only a fold gives up the pots.
+func computePots(table PokerTable) []TablePot {
+	names := make([]string, 0, len(table.Players))
+	for name := range table.Players {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	levels := make([]int, 0)
+	for _, name := range names {
+		player := table.Players[name]
+		if !player.IsFolded && !slices.Contains(levels, player.committed()) {
+			levels = append(levels, player.committed())
+		}
+	}
+	slices.Sort(levels)
+
+	pots := make([]TablePot, 0, len(levels))
+	prev := 0
+	for _, level := range levels {
+		pot := TablePot{Eligible: make([]string, 0)}
+		for _, name := range names {
+			player := table.Players[name]
+			pot.Amount += max(0, min(player.committed(), level)-prev)
+			if !player.IsFolded && player.committed() >= level {
+				pot.Eligible = append(pot.Eligible, name)
+			}
+		}
+
+		if pot.Amount > 0 || len(pots) == 0 {
+			pots = append(pots, pot)
+		}
+		prev = level
+	}
+
+	// whatever folded players put in over the top level goes with the last pot
+	if len(pots) > 0 {
+		for _, name := range names {
+			pots[len(pots)-1].Amount += max(0, table.Players[name].committed()-prev)
+		}
+	}
+
+	return pots
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	unet "poker-client/ups_net"
+)
+
+// reconcile overwrites local with the server's value, returns 1 if they differed
+func reconcile[T comparable](what string, local *T, server T) int {
+	if *local == server {
+		return 0
+	}
+
+	fmt.Printf("Drift: %s local=%v server=%v\n", what, *local, server)
+	*local = server
+	return 1
+}
+
+// reconcileTable diffs a full snapshot against the local table, everything
+// the server says wins
+func reconcileTable(ctx *ProgCtx, m *unet.RoomStateMsg) int {
+	table := &ctx.State.Table
+	if table.Players == nil {
+		table.Players = make(map[string]PlayerData)
+	}
+
+	drifted := reconcile("pot", &table.Pot, m.Pot)
+	drifted += reconcile("high bet", &table.HighBet, m.HighBet)
+
+	// seats and the button are only guessed locally, they don't count as drift
+	applySeats(table, m)
+
+	local := make([]int, 0, len(table.CommunityCards))
+	for _, card := range table.CommunityCards {
+		local = append(local, card.ID)
+	}
+	if !slices.Equal(local, m.CommunityCards) {
+		fmt.Printf("Drift: community cards local=%v server=%v\n", local, m.CommunityCards)
+		table.CommunityCards = make([]Card, 0, len(m.CommunityCards))
+		for _, cardID := range m.CommunityCards {
+			table.CommunityCards = append(table.CommunityCards, Card{ID: cardID, Symbol: TranslateCardID(cardID)})
+		}
+		drifted++
+	}
+
+	seated := make(map[string]bool)
+	for _, p := range m.Players {
+		seated[p.Nick] = true
+		drifted += reconcilePlayer(ctx, p)
+	}
+
+	for name := range table.Players {
+		if !seated[name] {
+			fmt.Printf("Drift: %s isn't seated anymore\n", name)
+			delete(table.Players, name)
+			drifted++
+		}
+	}
+
+	if m.CardsDealt {
+		me := table.Players[ctx.State.Nickname]
+		if len(me.Cards) != 2 || me.Cards[0].ID != m.Card1 || me.Cards[1].ID != m.Card2 || me.Cards[0].Hidden {
+			fmt.Printf("Drift: own cards, server has %d %d\n", m.Card1, m.Card2)
+			me.Cards = []Card{
+				{ID: m.Card1, Symbol: TranslateCardID(m.Card1)},
+				{ID: m.Card2, Symbol: TranslateCardID(m.Card2)},
+			}
+			table.Players[ctx.State.Nickname] = me
+			drifted++
+		}
+	}
+
+	return drifted
+}
+
+// reconcilePlayer diffs one player, the cards stay since the server only sends our own
+func reconcilePlayer(ctx *ProgCtx, p unet.PlayerSnapshot) int {
+	server := playerFromSnapshot(p)
+
+	local, exists := ctx.State.Table.Players[p.Nick]
+	if !exists {
+		fmt.Printf("Drift: %s was missing locally\n", p.Nick)
+		ctx.State.Table.Players[p.Nick] = server
+		return 1
+	}
+
+	drifted := reconcile(p.Nick+" chips", &local.ChipCount, server.ChipCount)
+	drifted += reconcile(p.Nick+" round bet", &local.RoundBet, server.RoundBet)
+	drifted += reconcile(p.Nick+" total bet", &local.TotalBet, server.TotalBet)
+	drifted += reconcile(p.Nick+" folded", &local.IsFolded, server.IsFolded)
+	drifted += reconcile(p.Nick+" ready", &local.IsReady, server.IsReady)
+	drifted += reconcile(p.Nick+" turn", &local.IsMyTurn, server.IsMyTurn)
+	drifted += reconcile(p.Nick+" action", &local.ActionTaken, server.ActionTaken)
+	drifted += reconcile(p.Nick+" action amount", &local.ActionAmount, server.ActionAmount)
+
+	// follows from the chips and the action, not worth their own log lines
+	local.IsAllIn = server.IsAllIn
+	local.HasLeft = server.HasLeft
+
+	ctx.State.Table.Players[p.Nick] = local
+	return drifted
+}
+
+// applyRoomUpdate handles the RMUP members that make sense inside a room
+func applyRoomUpdate(ctx *ProgCtx, m *unet.RoomUpdateMsg) (int, error) {
+	switch m.MemberID {
+	case unet.UpdateTable:
+		state := unet.RoomStateMsg{}
+		if err := m.DecodeValue(&state); err != nil {
+			return 0, err
+		}
+		return reconcileTable(ctx, &state), nil
+
+	case unet.UpdatePlayer:
+		player := unet.PlayerSnapshot{}
+		if err := m.DecodeValue(&player); err != nil {
+			return 0, err
+		}
+		return reconcilePlayer(ctx, player), nil
+
+	case unet.UpdatePot:
+		pot := unet.PotUpdate{}
+		if err := m.DecodeValue(&pot); err != nil {
+			return 0, err
+		}
+
+		drifted := reconcile("pot", &ctx.State.Table.Pot, pot.Pot)
+		drifted += reconcile("high bet", &ctx.State.Table.HighBet, pot.HighBet)
+		return drifted, nil
+	}
+
+	return 0, fmt.Errorf("unknown room update member %d", m.MemberID)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"slices"
+
+	unet "poker-client/ups_net"
+)
+
+// applySeats takes the seating from an RMST. Servers without the seat list
+// still send the players in seat order, only the dealer is unknown then.
+func applySeats(table *PokerTable, m *unet.RoomStateMsg) {
+	if len(m.Seats) > 0 {
+		table.Seats = slices.Clone(m.Seats)
+		table.Dealer = m.Dealer
+		return
+	}
+
+	table.Seats = make([]string, 0, len(m.Players))
+	for _, p := range m.Players {
+		table.Seats = append(table.Seats, p.Nick)
+	}
+	table.Dealer = -1
+}
+
+// seatPlayer puts someone that joined into the first free seat, the servers
+// fill seats the same way and the next RMST has the real one anyway
+func (t *PokerTable) seatPlayer(nick string) {
+	if slices.Contains(t.Seats, nick) {
+		return
+	}
+
+	if idx := slices.Index(t.Seats, ""); idx >= 0 {
+		t.Seats[idx] = nick
+		return
+	}
+	t.Seats = append(t.Seats, nick)
+}
+
+// moveDealer passes the button on at the start of a hand, like the server does
+func (t *PokerTable) moveDealer() {
+	if t.Dealer < 0 {
+		return
+	}
+
+	seats := t.seating()
+	for i := 1; i <= len(seats); i++ {
+		if next := (t.Dealer + i) % len(seats); seats[next] != "" {
+			t.Dealer = next
+			return
+		}
+	}
+}
+
+// seating is the nick by seat index, seats of players that already left are free
+func (t PokerTable) seating() []string {
+	seats := make([]string, len(t.Seats))
+	for i, nick := range t.Seats {
+		if _, ok := t.Players[nick]; ok {
+			seats[i] = nick
+		}
+	}
+	return seats
+}
+
+// blindSeats are the two seats after the dealer, heads up the dealer has the
+// small blind. The servers don't take blinds, they are only marked.
+func blindSeats(table PokerTable) (small, big int) {
+	seats := table.seating()
+	if table.Dealer < 0 || table.Dealer >= len(seats) {
+		return -1, -1
+	}
+
+	order := make([]int, 0, len(seats))
+	for i := 1; i <= len(seats); i++ {
+		if idx := (table.Dealer + i) % len(seats); seats[idx] != "" {
+			order = append(order, idx)
+		}
+	}
+
+	switch {
+	case len(order) < 2:
+		return -1, -1
+	case len(order) == 2 && order[1] == table.Dealer:
+		return table.Dealer, order[0]
+	}
+	return order[0], order[1]
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	unet "poker-client/ups_net"
+	w "poker-client/window"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const probeTimeout = 3 * time.Second
+
+type SavedServer struct {
+	Name string `json:"name"`
+	Host string `json:"host"`
+	Port string `json:"port"`
+}
+
+func (s SavedServer) Addr() string {
+	return net.JoinHostPort(s.Host, s.Port)
+}
+
+// ServerForm is the add/edit box under the server list
+type ServerForm struct {
+	Name    string
+	Host    string
+	Port    string
+	Editing int // index of the entry being edited, -1 adds a new one
+}
+
+func (f *ServerForm) Reset() {
+	*f = ServerForm{Editing: -1}
+}
+
+type StateServerList struct{}
+
+func (s *StateServerList) Enter(ctx *ProgCtx) {
+	fmt.Println("DFA: Entered Server List")
+	ctx.StateMutex.Lock()
+	ctx.State.Screen = ScreenServers
+	ctx.State.ServerForm.Reset()
+	servers := slices.Clone(ctx.State.Servers)
+	ctx.StateMutex.Unlock()
+
+	for _, server := range servers {
+		go probeServer(ctx, server)
+	}
+}
+
+func (s *StateServerList) HandleInput(ctx *ProgCtx, input UserInputEvent) LogicState {
+	switch evt := input.(type) {
+	case EvtConnect:
+		ctx.NetHandler.SendCommand(unet.NetConnect{Host: evt.Host, Port: evt.Port})
+
+	case EvtServerSave:
+		saveServerForm(ctx)
+
+	case EvtServerNew:
+		ctx.StateMutex.Lock()
+		ctx.State.ServerForm.Reset()
+		ctx.StateMutex.Unlock()
+
+	case EvtServerEdit:
+		ctx.StateMutex.Lock()
+		if evt.Index >= 0 && evt.Index < len(ctx.State.Servers) {
+			server := ctx.State.Servers[evt.Index]
+			ctx.State.ServerForm = ServerForm{Name: server.Name, Host: server.Host, Port: server.Port, Editing: evt.Index}
+		}
+		ctx.StateMutex.Unlock()
+
+	case EvtServerDelete:
+		ctx.StateMutex.Lock()
+		if evt.Index >= 0 && evt.Index < len(ctx.State.Servers) {
+			ctx.State.Servers = slices.Delete(ctx.State.Servers, evt.Index, evt.Index+1)
+
+			form := &ctx.State.ServerForm
+			switch {
+			case form.Editing == evt.Index:
+				form.Reset()
+			case form.Editing > evt.Index:
+				form.Editing--
+			}
+		}
+		ctx.StateMutex.Unlock()
+		saveServers(ctx)
+
+	case EvtServerPing:
+		ctx.StateMutex.RLock()
+		servers := slices.Clone(ctx.State.Servers)
+		ctx.StateMutex.RUnlock()
+
+		for i, server := range servers {
+			if evt.Index < 0 || evt.Index == i {
+				go probeServer(ctx, server)
+			}
+		}
+
+	case EvtBackToMain:
+		return &StateMainMenu{}
+	}
+
+	return nil
+}
+
+func (s *StateServerList) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState {
+	switch msg.(type) {
+	case unet.NetConnecting:
+		ctx.StateMutex.Lock()
+		ctx.State.Screen = ScreenConnecting
+		ctx.StateMutex.Unlock()
+		return &StateConnecting{false}
+	}
+
+	return nil
+}
+
+func (s *StateServerList) Exit(ctx *ProgCtx) {}
+
+// saveServerForm adds or replaces the entry from the form
+func saveServerForm(ctx *ProgCtx) {
+	ctx.StateMutex.Lock()
+	form := ctx.State.ServerForm
+	server := SavedServer{
+		Name: strings.TrimSpace(form.Name),
+		Host: strings.TrimSpace(form.Host),
+		Port: strings.TrimSpace(form.Port),
+	}
+
+	port, err := strconv.Atoi(server.Port)
+	switch {
+	case server.Name == "" || server.Host == "":
+		ctx.StateMutex.Unlock()
+		ctx.Popup.AddPopup("The server needs a name and an address", 2*time.Second)
+		return
+	case err != nil || port <= 0 || port > 65535:
+		ctx.StateMutex.Unlock()
+		ctx.Popup.AddPopup("Invalid port", 2*time.Second)
+		return
+	}
+
+	if form.Editing >= 0 && form.Editing < len(ctx.State.Servers) {
+		ctx.State.Servers[form.Editing] = server
+	} else {
+		ctx.State.Servers = append(ctx.State.Servers, server)
+	}
+	ctx.State.ServerForm.Reset()
+	ctx.StateMutex.Unlock()
+
+	saveServers(ctx)
+	go probeServer(ctx, server)
+}
+
+func saveServers(ctx *ProgCtx) {
+	if ctx.ConfigPath == "" {
+		return
+	}
+
+	ctx.StateMutex.RLock()
+	servers := slices.Clone(ctx.State.Servers)
+	ctx.StateMutex.RUnlock()
+
+	if err := updateConfigFile(ctx.ConfigPath, func(cfg *Config) { cfg.Servers = servers }); err != nil {
+		fmt.Println("Failed to save the server list:", err)
+		ctx.Popup.AddPopup("Failed to save the server list", 3*time.Second)
+	}
+}
+
+// probeServer runs on its own goroutine, results are kept per address so
+// edits in the meantime don't mix them up
+func probeServer(ctx *ProgCtx, server SavedServer) {
+	addr := server.Addr()
+
+	ctx.StateMutex.Lock()
+	ctx.State.ServerPings[addr] = "..."
+	ctx.StateMutex.Unlock()
+
+	result := "offline"
+	if latency, err := unet.Probe(server.Host, server.Port, probeTimeout); err == nil {
+		result = fmt.Sprintf("%d ms", latency.Milliseconds())
+	} else {
+		fmt.Printf("Probe of %s failed: %v\n", addr, err)
+	}
+
+	ctx.StateMutex.Lock()
+	ctx.State.ServerPings[addr] = result
+	ctx.StateMutex.Unlock()
+}
+
+func buildServerListUI(ctx *ProgCtx) UIElement {
+	list := w.NewVStack(5)
+	list.AddChild(w.NewLabelComponent("Servers", 24, rl.White))
+
+	ctx.StateMutex.RLock()
+	servers := slices.Clone(ctx.State.Servers)
+	pings := make([]string, len(servers))
+	for i, server := range servers {
+		pings[i] = ctx.State.ServerPings[server.Addr()]
+	}
+	editing := ctx.State.ServerForm.Editing
+	ctx.StateMutex.RUnlock()
+
+	if len(servers) == 0 {
+		list.AddChild(w.NewCenterComponent(w.NewLabelComponent("No saved servers.", 18, rl.Gray)))
+	}
+
+	for i, server := range servers {
+		color := rl.White
+		if i == editing {
+			color = rl.Gold
+		}
+
+		id := strconv.Itoa(i)
+		row := w.NewHStack(10)
+		row.AddChild(w.NewLabelComponent(fmt.Sprintf("%s  %s  %s", server.Name, server.Addr(), pings[i]), 18, color))
+		row.AddChild(w.NewCenterComponent(w.NewButtonComponent("server_connect_"+id, "Connect", 100, 40)))
+		row.AddChild(w.NewCenterComponent(w.NewButtonComponent("server_ping_"+id, "Ping", 80, 40)))
+		row.AddChild(w.NewCenterComponent(w.NewButtonComponent("server_edit_"+id, "Edit", 80, 40)))
+		row.AddChild(w.NewCenterComponent(w.NewButtonComponent("server_delete_"+id, "Delete", 80, 40)))
+		list.AddChild(row)
+	}
+
+	form := w.NewHStack(5)
+	form.AddChild(w.NewLabelComponent("Name:", 18, rl.White))
+	form.AddChild(buildCenteredTextBox("Servers_NameBox", &ctx.State.ServerForm.Name, 32))
+	form.AddChild(w.NewLabelComponent("Address:", 18, rl.White))
+	form.AddChild(buildCenteredTextBox("Servers_HostBox", &ctx.State.ServerForm.Host, 64))
+	form.AddChild(w.NewLabelComponent("Port:", 18, rl.White))
+	form.AddChild(buildCenteredTextBox("Servers_PortBox", &ctx.State.ServerForm.Port, 6))
+	list.AddChild(form)
+
+	saveText := "Add"
+	if editing >= 0 {
+		saveText = "Save"
+	}
+
+	buttons := w.NewHStack(10)
+	buttons.AddChild(w.NewCenterComponent(w.NewButtonComponent("Servers_SaveBtn", saveText, 150, 50)))
+	buttons.AddChild(w.NewCenterComponent(w.NewButtonComponent("Servers_NewBtn", "Clear", 150, 50)))
+	buttons.AddChild(w.NewCenterComponent(w.NewButtonComponent("Servers_PingAllBtn", "Ping all", 150, 50)))
+	buttons.AddChild(w.NewCenterComponent(w.NewButtonComponent("Servers_BackBtn", "Back", 150, 50)))
+	list.AddChild(buttons)
+
+	panel := w.NewPanelComponent(rl.DarkGray, list)
+	return UIElement{dirty: true, component: w.NewBoundsBox(0.7, 0.8, panel)}
+}
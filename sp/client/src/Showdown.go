@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"poker-client/poker"
+)
+
+// ShowdownResults collects what the server announced between SDWN and GMDN
+type ShowdownResults struct {
+	Active bool
+	Wins   map[string]int // GWIN nick -> amount, more than one on a split pot
+	Lost   bool           // GLOS
+}
+
+func (r *ShowdownResults) Begin() {
+	*r = ShowdownResults{Active: true, Wins: make(map[string]int)}
+}
+
+func (r *ShowdownResults) AddWin(nick string, amount int) {
+	if r.Wins == nil {
+		r.Wins = make(map[string]int)
+	}
+	r.Wins[nick] += amount
+}
+
+// bestOf picks who holds the best revealed hand among names
+func bestOf(table PokerTable, names []string) ([]string, error) {
+	// last one standing doesn't have to show anything
+	if len(names) <= 1 {
+		return names, nil
+	}
+
+	// players that were never dealt in have nothing to reveal
+	shown := make([]string, 0, len(names))
+	hands := make([]poker.Hand, 0, len(names))
+	for _, name := range names {
+		hand, ok := evaluateCards(table.Players[name].Cards, table.CommunityCards)
+		if !ok {
+			fmt.Println("Showdown: skipping", name, "no cards revealed")
+			continue
+		}
+		shown = append(shown, name)
+		hands = append(hands, hand)
+	}
+
+	if len(hands) == 0 {
+		return nil, errors.New("no hands were revealed")
+	}
+
+	winners := make([]string, 0)
+	for _, idx := range poker.Best(hands) {
+		winners = append(winners, shown[idx])
+	}
+	return winners, nil
+}
+
+// expectedPayouts splits every pot between the best hands eligible for it
+func expectedPayouts(table PokerTable, pots []TablePot) (map[string]int, error) {
+	payouts := make(map[string]int)
+	for _, pot := range pots {
+		winners, err := bestOf(table, pot.Eligible)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, nick := range winners {
+			payouts[nick] += pot.Amount / len(winners)
+		}
+	}
+	return payouts, nil
+}
+
+// verifyShowdown lists everything the server's result disagrees on, empty if it checks out
+func verifyShowdown(table PokerTable, results ShowdownResults) []string {
+	problems := make([]string, 0)
+
+	inHand := 0
+	for _, player := range table.Players {
+		if !player.IsFolded {
+			inHand++
+		}
+	}
+
+	if inHand == 0 {
+		if !results.Lost {
+			problems = append(problems, "everyone folded but the server didn't send GLOS")
+		}
+		for nick, amount := range results.Wins {
+			problems = append(problems, fmt.Sprintf("%s was paid %d with nobody left in the hand", nick, amount))
+		}
+		return problems
+	}
+
+	pots := computePots(table)
+	expected, err := expectedPayouts(table, pots)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	if results.Lost {
+		winners := make([]string, 0, len(expected))
+		for nick := range expected {
+			winners = append(winners, nick)
+		}
+		sort.Strings(winners)
+		problems = append(problems, fmt.Sprintf("server sent GLOS, expected %s to win", strings.Join(winners, ", ")))
+	}
+
+	// odd chips can go to any of the winners, so allow one per pot
+	for nick, share := range expected {
+		amount := results.Wins[nick]
+		if amount >= share && amount <= share+len(pots) {
+			continue
+		}
+
+		if amount == 0 {
+			problems = append(problems, fmt.Sprintf("%s should have won %d but got nothing", nick, share))
+		} else {
+			problems = append(problems, fmt.Sprintf("%s got %d, expected %d", nick, amount, share))
+		}
+	}
+
+	for nick, amount := range results.Wins {
+		if _, ok := expected[nick]; !ok {
+			problems = append(problems, fmt.Sprintf("%s got %d but doesn't hold the best hand", nick, amount))
+		}
+	}
+
+	total := 0
+	for _, amount := range results.Wins {
+		total += amount
+	}
+	if total != table.Pot {
+		problems = append(problems, fmt.Sprintf("server paid out %d of a %d pot", total, table.Pot))
+	}
+
+	return problems
+}
+
+// checkShowdown runs once per hand on GMDN, before the table is reset
+func checkShowdown(ctx *ProgCtx) {
+	results := ctx.State.Results
+	ctx.State.Results = ShowdownResults{}
+	if !results.Active {
+		return
+	}
+
+	problems := verifyShowdown(ctx.State.Table, results)
+	if len(problems) == 0 {
+		return
+	}
+
+	for _, problem := range problems {
+		fmt.Println("Showdown mismatch:", problem)
+	}
+	ctx.Popup.AddPopup("Warning: showdown result doesn't match the cards: "+problems[0], 8*time.Second)
+}
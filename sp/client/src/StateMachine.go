@@ -9,6 +9,9 @@ import (
 	unet "poker-client/ups_net"
 )
 
+// How long the server gets to acknowledge a request before the connection is reset
+const ackTimeout = 5 * time.Second
+
 func TranslateCardID(id int) string {
 	if id < 0 || id > 51 {
 		return "??"
@@ -27,6 +30,17 @@ func TranslateCardID(id int) string {
 	return ranks[rankIdx] + " of " + suits[suitIdx]
 }
 
+// decodeNetMsg returns nil for unknown codes, malformed payloads are reported through err
+func decodeNetMsg(msg unet.NetMsg) (unet.Message, error) {
+	typed, err := unet.DecodeMsg(msg)
+	if errors.Is(err, unet.ErrUnknownCode) {
+		fmt.Println("DFA: Ignoring unknown message", msg.Code)
+		return nil, nil
+	}
+
+	return typed, err
+}
+
 type LogicState interface {
 	Enter(ctx *ProgCtx)
 	HandleInput(ctx *ProgCtx, input UserInputEvent) LogicState
@@ -40,6 +54,7 @@ func (s *StateMainMenu) Enter(ctx *ProgCtx) {
 	fmt.Println("DFA: Entered Menu State")
 	ctx.StateMutex.Lock()
 	ctx.State.Screen = ScreenMainMenu
+	ctx.State.Chat.Clear()
 	ctx.StateMutex.Unlock()
 }
 
@@ -47,6 +62,9 @@ func (s *StateMainMenu) HandleInput(ctx *ProgCtx, input UserInputEvent) LogicSta
 	switch evt := input.(type) {
 	case EvtConnect:
 		ctx.NetHandler.SendCommand(unet.NetConnect{Host: evt.Host, Port: evt.Port})
+
+	case EvtOpenServers:
+		return &StateServerList{}
 	}
 
 	return nil
@@ -72,18 +90,21 @@ type StateConnecting struct {
 
 func (s *StateConnecting) Enter(ctx *ProgCtx) {
 	fmt.Println("DFA: Entered Connecting State")
-	nickPayload, ok := unet.WriteString(ctx.State.Nickname)
-	if !ok {
+	err := ctx.NetHandler.SendMsg(&unet.ConnMsg{
+		Nick:         ctx.State.Nickname,
+		Version:      unet.ProtocolVersion,
+		Capabilities: unet.SupportedCapabilities,
+	})
+	if err != nil {
 		ctx.NetHandler.SendCommand(unet.NetDisconnect{})
 		ctx.Popup.AddPopup("Failed parsing, catastrophe has happened", time.Second*5)
 	}
-	ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "CONN", Payload: nickPayload})
 }
 
 func (s *StateConnecting) HandleInput(ctx *ProgCtx, input UserInputEvent) LogicState {
 	switch input.(type) {
 	case EvtAcceptReconnect:
-		ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "RCON"})
+		ctx.NetHandler.Request(&unet.ReconnectMsg{}, []string{unet.CodeRoomState}, ackTimeout)
 		return &StateJoiningRoom{}
 
 	case EvtDeclineReconnect:
@@ -102,29 +123,39 @@ func (s *StateConnecting) HandleInput(ctx *ProgCtx, input UserInputEvent) LogicS
 func (s *StateConnecting) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState {
 	switch evt := msg.(type) {
 	case unet.NetMessage:
-		switch evt.Msg.Code {
-		case "PNOK":
-			fmt.Println("DFA: Nick accepted.")
+		typed, err := decodeNetMsg(evt.Msg)
+		if err != nil {
+			fmt.Println("DFA: Malformed handshake message:", err)
+			ctx.NetHandler.SendCommand(unet.NetDisconnect{})
+			return &StateMainMenu{}
+		}
+
+		switch typed.(type) {
+		case *unet.NickOKMsg:
+			info := ctx.NetHandler.ServerInfo()
+			fmt.Printf("DFA: Nick accepted. Protocol v%d, capabilities %v\n", info.Version, info.Capabilities)
+			rememberServer(ctx)
 			return &StateSendingInfo{}
 
-		case "FULL":
+		case *unet.FullMsg:
 			fmt.Println("Server full")
 			ctx.Popup.AddPopup("Server full", time.Second*3)
 			ctx.NetHandler.SendCommand(unet.NetDisconnect{})
 			return &StateMainMenu{}
 
-		case "FAIL":
+		case *unet.FailMsg:
 			fmt.Println("DFA: Connection Failed (FAIL).")
 			ctx.NetHandler.SendCommand(unet.NetDisconnect{})
 			return &StateMainMenu{}
 
-		case "RCON":
+		case *unet.ReconnectMsg:
+			rememberServer(ctx)
 			if !s.reconnecting {
 				ctx.StateMutex.Lock()
 				ctx.State.Screen = ScreenReconnecting
 				ctx.StateMutex.Unlock()
 			} else {
-				ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "RCON"})
+				ctx.NetHandler.Request(&unet.ReconnectMsg{}, []string{unet.CodeRoomState}, ackTimeout)
 				ctx.State.Reconnected = true
 				return &StateJoiningRoom{}
 			}
@@ -150,15 +181,17 @@ type StateSendingInfo struct{}
 
 func (s *StateSendingInfo) Enter(ctx *ProgCtx) {
 	data, _ := ctx.State.Table.Players[ctx.State.Nickname]
-	chipStr, ok := unet.WriteVarInt(data.ChipCount)
+	err := ctx.NetHandler.Request(
+		&unet.InfoMsg{Chips: data.ChipCount},
+		[]string{unet.CodeInfoOK, unet.CodeFail},
+		ackTimeout,
+	)
 
-	if !ok {
+	if err != nil {
 		ctx.NetHandler.SendCommand(unet.NetDisconnect{})
 		ctx.Popup.AddPopup("Failed parsing, catastrophe has happened", time.Second*5)
 		return
 	}
-
-	ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "PINF", Payload: chipStr})
 }
 
 func (s *StateSendingInfo) HandleInput(ctx *ProgCtx, input UserInputEvent) LogicState {
@@ -168,17 +201,22 @@ func (s *StateSendingInfo) HandleInput(ctx *ProgCtx, input UserInputEvent) Logic
 func (s *StateSendingInfo) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState {
 	switch evt := msg.(type) {
 	case unet.NetMessage:
-		switch evt.Msg.Code {
-		case "PIOK":
+		typed, _ := decodeNetMsg(evt.Msg)
+
+		switch typed.(type) {
+		case *unet.InfoOKMsg:
 			fmt.Println("DFA: Info Accepted")
 			return &StateRequestingRooms{}
 
-		case "FAIL":
+		case *unet.FailMsg:
 			fmt.Println("DFA: Player Info Rejected.")
 			ctx.NetHandler.SendCommand(unet.NetDisconnect{})
 			return &StateMainMenu{}
 		}
 
+	case unet.NetRequestTimeout:
+		ctx.Popup.AddPopup("Server didn't accept player info in time", time.Second*3)
+
 	case unet.NetReconnected:
 		return &StateConnecting{false}
 
@@ -194,9 +232,12 @@ func (s *StateSendingInfo) Exit(ctx *ProgCtx) {}
 
 type StateRequestingRooms struct{}
 
+// every step of the room list is answered by the next room or the end of it
+var roomListCodes = []string{unet.CodeRoom, unet.CodeRoomsDone}
+
 func (s *StateRequestingRooms) Enter(ctx *ProgCtx) {
 	fmt.Println("DFA: Requesting Rooms...")
-	ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "RMRQ"})
+	ctx.NetHandler.Request(&unet.RoomRequestMsg{}, roomListCodes, ackTimeout)
 
 	ctx.StateMutex.Lock()
 	ctx.State.Screen = ScreenWaitingForRooms
@@ -211,20 +252,28 @@ func (s *StateRequestingRooms) HandleInput(ctx *ProgCtx, input UserInputEvent) L
 func (s *StateRequestingRooms) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState {
 	switch evt := msg.(type) {
 	case unet.NetMessage:
-		switch evt.Msg.Code {
-		case "ROOM":
-			err := handleRoomData(ctx, evt.Msg)
-			if err == nil {
-				ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "RMOK"})
-			} else {
-				ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "RMFL"})
+		typed, err := decodeNetMsg(evt.Msg)
+		if err != nil {
+			fmt.Println("DFA: Malformed room list message:", err)
+			if evt.Msg.Code == unet.CodeRoom {
+				ctx.NetHandler.Request(&unet.RoomFailMsg{}, roomListCodes, ackTimeout)
 			}
+			return nil
+		}
 
-		case "DONE":
-			ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "DNOK"})
+		switch m := typed.(type) {
+		case *unet.RoomMsg:
+			handleRoomData(ctx, m)
+			ctx.NetHandler.Request(&unet.RoomOKMsg{}, roomListCodes, ackTimeout)
+
+		case *unet.RoomsDoneMsg:
+			ctx.NetHandler.SendMsg(&unet.DoneOKMsg{})
 			return &StateLobby{}
 		}
 
+	case unet.NetRequestTimeout:
+		ctx.Popup.AddPopup("Server stopped sending rooms", time.Second*3)
+
 	case unet.NetReconnected:
 		return &StateConnecting{false}
 
@@ -252,25 +301,81 @@ func (s *StateLobby) HandleInput(ctx *ProgCtx, input UserInputEvent) LogicState
 	switch evt := input.(type) {
 	case EvtRoomJoin:
 		idInt, _ := strconv.Atoi(evt.RoomID)
-		payload := fmt.Sprintf("%04d", idInt)
 
-		fmt.Printf("DFA: Joining Room %s with payload %s\n", evt.RoomID, payload)
-		ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "JOIN", Payload: payload})
+		fmt.Printf("DFA: Joining Room %s\n", evt.RoomID)
+		ctx.StateMutex.Lock()
+		ctx.State.RoomID = idInt
+		ctx.StateMutex.Unlock()
+
+		ctx.NetHandler.Request(
+			&unet.JoinMsg{RoomID: idInt},
+			[]string{unet.CodeJoinOK, unet.CodeJoinFail},
+			ackTimeout,
+		)
 		return &StateJoiningRoom{}
 
+	case EvtRoomSpectate:
+		// older servers drop the connection on SPEC
+		if !ctx.NetHandler.HasCapability(unet.CapSpectate) {
+			ctx.Popup.AddPopup("This server doesn't allow watching", 2*time.Second)
+			return nil
+		}
+
+		idInt, _ := strconv.Atoi(evt.RoomID)
+
+		fmt.Printf("DFA: Spectating Room %s\n", evt.RoomID)
+		ctx.StateMutex.Lock()
+		ctx.State.RoomID = idInt
+		ctx.StateMutex.Unlock()
+
+		ctx.NetHandler.Request(
+			&unet.SpectateMsg{RoomID: idInt},
+			[]string{unet.CodeSpectateOK, unet.CodeSpectateFail},
+			ackTimeout,
+		)
+		return &StateJoiningRoom{spectating: true}
+
 	case EvtBackToMain:
 		ctx.NetHandler.SendCommand(unet.NetDisconnect{})
 		return &StateMainMenu{}
 
 	case EvtRefreshRooms:
 		return &StateRequestingRooms{}
+
+	case EvtChat:
+		sendChat(ctx, evt.Text)
 	}
 
 	return nil
 }
 
 func (s *StateLobby) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState {
-	switch msg.(type) {
+	switch evt := msg.(type) {
+	case unet.NetMessage:
+		typed, err := decodeNetMsg(evt.Msg)
+		if err != nil {
+			fmt.Println("DFA: Malformed lobby message:", err)
+			if evt.Msg.Code == unet.CodeRoomUpdate {
+				ctx.NetHandler.SendMsg(&unet.UpdateFailMsg{})
+			}
+			return nil
+		}
+
+		switch m := typed.(type) {
+		case *unet.RoomUpdateMsg:
+			if err := applyLobbyUpdate(ctx, m); err != nil {
+				fmt.Println("DFA: Failed to apply room update:", err)
+				ctx.NetHandler.SendMsg(&unet.UpdateFailMsg{})
+				return nil
+			}
+			ctx.NetHandler.SendMsg(&unet.UpdateOKMsg{})
+
+		case *unet.ChatMessageMsg, *unet.ChatFailMsg:
+			ctx.StateMutex.Lock()
+			handleChatMessage(ctx, m)
+			ctx.StateMutex.Unlock()
+		}
+
 	case unet.NetReconnected:
 		return &StateConnecting{false}
 
@@ -283,7 +388,9 @@ func (s *StateLobby) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState {
 
 func (s *StateLobby) Exit(ctx *ProgCtx) {}
 
-type StateJoiningRoom struct{}
+type StateJoiningRoom struct {
+	spectating bool
+}
 
 func (s *StateJoiningRoom) Enter(ctx *ProgCtx) {}
 
@@ -294,35 +401,52 @@ func (s *StateJoiningRoom) HandleInput(ctx *ProgCtx, input UserInputEvent) Logic
 func (s *StateJoiningRoom) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState {
 	switch evt := msg.(type) {
 	case unet.NetMessage:
-		switch evt.Msg.Code {
-		case "JNOK":
+		typed, err := decodeNetMsg(evt.Msg)
+		if err != nil {
+			fmt.Printf("DFA: Failed to parse room state: %v\n", err)
+			ctx.NetHandler.SendMsg(&unet.StateFailMsg{})
+			ctx.Popup.AddPopup("Failed to join room: invalid state", time.Second*3)
+			return &StateLobby{}
+		}
+
+		switch m := typed.(type) {
+		case *unet.JoinOKMsg, *unet.SpectateOKMsg:
 			fmt.Println("DFA: Join OK. Waiting for Room State...")
 			return nil
 
-		case "RMST":
-			fmt.Println("DFA: Received Room State. Parsing...")
+		case *unet.RoomStateMsg:
+			fmt.Println("DFA: Received Room State.")
+
+			next := &StateInGame{spectating: s.spectating}
 
 			ctx.StateMutex.Lock()
-			err := deserializeRoomState(ctx, evt.Msg.Payload)
+			if s.spectating {
+				// not at the table, kept aside until the room is left
+				next.saved = ctx.State.Table.Players[ctx.State.Nickname]
+				delete(ctx.State.Table.Players, ctx.State.Nickname)
+				ctx.State.Spectating = true
+			}
+			applyRoomState(ctx, m)
 			ctx.StateMutex.Unlock()
 
-			if err != nil {
-				fmt.Printf("DFA: Failed to parse room state: %v\n", err)
-				ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "STFL"})
-				ctx.Popup.AddPopup("Failed to join room: invalid state", time.Second*3)
-				return &StateLobby{}
-			}
+			fmt.Println("DFA: Room State applied. Sending STOK.")
+			ctx.NetHandler.SendMsg(&unet.StateOKMsg{})
+			return next
 
-			fmt.Println("DFA: Room State parsed. Sending STOK.")
-			ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "STOK"})
-			return &StateInGame{}
+		case *unet.SpectateFailMsg:
+			fmt.Println("DFA: Spectate Failed.")
+			ctx.Popup.AddPopup("Failed to watch room", time.Second*3)
+			return &StateLobby{}
 
-		case "JNFL":
+		case *unet.JoinFailMsg:
 			fmt.Println("DFA: Join Failed.")
 			ctx.Popup.AddPopup("Failed to join room", time.Second*3)
 			return &StateLobby{}
 		}
 
+	case unet.NetRequestTimeout:
+		ctx.Popup.AddPopup("Server didn't answer the join in time", time.Second*3)
+
 	case unet.NetReconnected:
 		ctx.Popup.AddPopup("Failed to join room", time.Second*3)
 		return &StateConnecting{true}
@@ -342,6 +466,7 @@ func (s *StateJoiningRoom) Exit(ctx *ProgCtx) {}
 
 type GameAction any
 type BetAction struct{ amount int }
+type RaiseAction struct{ to int }
 type CallAction struct{ amount int }
 type CheckAction struct{}
 type FoldAction struct{}
@@ -349,11 +474,16 @@ type ReadyAction struct{}
 
 type StateInGame struct {
 	last_action GameAction
+
+	spectating bool
+	saved      PlayerData // own data while spectating, the table has no seat for it
 }
 
 func (s *StateInGame) Enter(ctx *ProgCtx) {
 	ctx.StateMutex.Lock()
 	ctx.State.Screen = ScreenInGame
+	// the lobby can't hear the room, so neither log carries over
+	ctx.State.Chat.Clear()
 	ctx.StateMutex.Unlock()
 	ctx.UI.SetDirty()
 }
@@ -363,6 +493,11 @@ func (s *StateInGame) HandleInput(ctx *ProgCtx, input UserInputEvent) LogicState
 	case EvtGameAction:
 		fmt.Println("DFA: Sending Game Action ->", evt.Action, evt.Amount)
 
+		if s.spectating && evt.Action != unet.CodeLeave {
+			fmt.Println("DFA: Spectators can't act")
+			return nil
+		}
+
 		// Validate action before sending
 		if !validateGameAction(ctx, evt.Action, evt.Amount) {
 			return nil
@@ -372,11 +507,16 @@ func (s *StateInGame) HandleInput(ctx *ProgCtx, input UserInputEvent) LogicState
 
 		switch evt.Action {
 		case "BETT":
+			// both are BETT on the wire, the amount is the new round bet
 			intAmount, _ := unet.ReadVarInt([]byte(evt.Amount))
-			s.last_action = BetAction{int(intAmount)}
+			if ctx.State.Table.HighBet > 0 {
+				s.last_action = RaiseAction{int(intAmount)}
+			} else {
+				s.last_action = BetAction{int(intAmount)}
+			}
 		case "CALL":
 			myData, _ := ctx.State.Table.Players[ctx.State.Nickname]
-			callAmount := min(myData.ChipCount, ctx.State.Table.HighBet)
+			callAmount := min(myData.ChipCount, ctx.State.Table.HighBet-myData.RoundBet)
 			s.last_action = CallAction{callAmount}
 		case "RDY1":
 			s.last_action = ReadyAction{}
@@ -388,9 +528,12 @@ func (s *StateInGame) HandleInput(ctx *ProgCtx, input UserInputEvent) LogicState
 			return &StateLobby{}
 		}
 
+	case EvtChat:
+		sendChat(ctx, evt.Text)
+
 	case EvtBackToMain:
 		fmt.Println("DFA: Leaving Game")
-		ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "GMLV"})
+		ctx.NetHandler.SendMsg(&unet.LeaveMsg{})
 		return &StateLobby{}
 	}
 	return nil
@@ -403,112 +546,133 @@ func (s *StateInGame) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState
 
 	switch evt := msg.(type) {
 	case unet.NetMessage:
-		switch evt.Msg.Code {
-		case "PJIN":
-			handlePlayerJoined(ctx, evt.Msg.Payload)
+		typed, err := decodeNetMsg(evt.Msg)
+		if err != nil {
+			// a half applied message means a desync, reconnecting resyncs through RMST
+			fmt.Println("DFA: Malformed game message:", err)
+			ctx.Popup.AddPopup("Error during parsing, disconnecting", time.Second*3)
+			ctx.NetHandler.SendCommand(unet.NetDisconnect{})
+			return &StateMainMenu{}
+		}
+
+		if handleChatMessage(ctx, typed) {
+			return nil
+		}
+
+		switch m := typed.(type) {
+		case *unet.PlayerJoinedMsg:
+			ctx.State.Table.Players[m.Player.Nick] = playerFromSnapshot(m.Player)
+			ctx.State.Table.seatPlayer(m.Player.Nick)
 			ctx.Popup.AddPopup("A player has joined", 2*time.Second)
 
-		case "PRDY":
-			nick, _ := unet.ReadString([]byte(evt.Msg.Payload))
-			data, _ := ctx.State.Table.Players[nick]
+		case *unet.PlayerReadyMsg:
+			data, _ := ctx.State.Table.Players[m.Nick]
 			data.IsReady = true
-			ctx.State.Table.Players[nick] = data
+			ctx.State.Table.Players[m.Nick] = data
 
-		case "GMST":
+		case *unet.GameStartMsg:
 			fmt.Println("Game Started!")
+			ctx.State.History.StartHand(time.Now())
+			ctx.Hands.Start(&ctx.State.Table, currentRoomName(ctx), time.Now())
 			ctx.State.Table.RoundPhase = "PreFlop"
-			myData, _ := ctx.State.Table.Players[ctx.State.Nickname]
-			myData.Cards = make([]Card, 0)
-			ctx.State.Table.Players[ctx.State.Nickname] = myData
+			ctx.State.Table.moveDealer()
+			// the server deals nobody in that has no chips left
+			for name, player := range ctx.State.Table.Players {
+				if player.ChipCount <= 0 {
+					player.IsFolded = true
+					ctx.State.Table.Players[name] = player
+				}
+			}
+			if myData, seated := ctx.State.Table.Players[ctx.State.Nickname]; seated {
+				myData.Cards = make([]Card, 0)
+				ctx.State.Table.Players[ctx.State.Nickname] = myData
+			}
 			ctx.State.Table.CommunityCards = make([]Card, 0)
 			ctx.State.Table.Pot = 0
 			ctx.State.Table.HighBet = 0
+			ctx.State.Table.LastRaise = 0
 			ctx.Popup.AddPopup("Game started!", 2*time.Second)
 
-		case "CDTP":
+		case *unet.CardsToPlayerMsg:
 			myData, _ := ctx.State.Table.Players[ctx.State.Nickname]
-			myData.Cards = make([]Card, 0)
-
-			parseTypes := []unet.ParseTypes{
-				unet.SmallInt,
-				unet.SmallInt,
+			myData.Cards = []Card{
+				{ID: m.Card1, Symbol: TranslateCardID(m.Card1)},
+				{ID: m.Card2, Symbol: TranslateCardID(m.Card2)},
 			}
 
-			results, _, err := unet.ParseMessage(evt.Msg.Payload, parseTypes)
-			if err != nil {
-				ctx.Popup.AddPopup("Error during parsing, disconnecting", time.Second*3)
-				ctx.NetHandler.SendCommand(unet.NetDisconnect{})
-				return &StateMainMenu{}
-			}
-
-			c1 := results[0].(int)
-			c2 := results[1].(int)
-			myData.Cards = append(myData.Cards,
-				Card{ID: c1, Symbol: TranslateCardID(c1)},
-				Card{ID: c2, Symbol: TranslateCardID(c2)},
-			)
-
 			ctx.State.Table.Players[ctx.State.Nickname] = myData
-			ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "CDOK"})
+			ctx.Hands.Dealt(ctx.State.Nickname, myData.Cards)
+			ctx.NetHandler.SendMsg(&unet.CardsOKMsg{})
 
-		case "GMRD":
+		case *unet.GameRoundMsg:
 			fmt.Println("Handling Game Round")
+			ctx.State.History.StartRound(roundName(len(ctx.State.Table.CommunityCards)))
+			ctx.Hands.Board(ctx.State.Table.CommunityCards)
 			ctx.State.Table.HighBet = 0
+			ctx.State.Table.LastRaise = 0
 			for name, player := range ctx.State.Table.Players {
 				player.TotalBet += player.RoundBet
 				player.RoundBet = 0
-				player.ActionTaken = "NONE"
 				player.ActionAmount = 0
+				player.IsMyTurn = false
+
+				// same as the server, folds stay visible for the rest of the hand
+				if player.ActionTaken != "FOLD" && player.ActionTaken != "LEFT" {
+					player.ActionTaken = "NONE"
+				}
 				ctx.State.Table.Players[name] = player
 			}
 
-		case "CRVR":
-			val, _ := strconv.Atoi(evt.Msg.Payload)
-			newCard := Card{ID: val, Symbol: TranslateCardID(val)}
+		case *unet.CommunityCardMsg:
+			newCard := Card{ID: m.Card, Symbol: TranslateCardID(m.Card)}
 			ctx.State.Table.CommunityCards = append(ctx.State.Table.CommunityCards, newCard)
+			ctx.State.History.Add("Board: %s", newCard.Symbol)
 			ctx.Popup.AddPopup(fmt.Sprintf("Community card: %s", newCard.Symbol), 2*time.Second)
 
-		case "PTRN":
-			playerName, _ := unet.ReadString([]byte(evt.Msg.Payload))
-
+		case *unet.PlayerTurnMsg:
+			ctx.State.History.Add("%s to act", m.Nick)
 			for name, data := range ctx.State.Table.Players {
-				data.IsMyTurn = (name == playerName)
+				data.IsMyTurn = (name == m.Nick)
 				ctx.State.Table.Players[name] = data
 			}
 
-			if playerName == ctx.State.Nickname {
-				data, _ := ctx.State.Table.Players[playerName]
+			if m.Nick == ctx.State.Nickname {
+				data, _ := ctx.State.Table.Players[m.Nick]
 				ctx.Popup.AddPopup(fmt.Sprintf("Your turn! [ %t | %t ]", data.IsMyTurn, data.IsFolded), time.Second*5)
 			}
 
-		case "TOUT":
-			playerName, _ := unet.ReadString([]byte(evt.Msg.Payload))
-			if playerName == ctx.State.Nickname {
+		case *unet.TimeoutMsg:
+			ctx.State.History.Add("%s timed out", m.Nick)
+			ctx.Hands.TimedOut(m.Nick)
+			if m.Nick == ctx.State.Nickname {
 				ctx.Popup.AddPopup("You timed out", time.Second*2)
 			} else {
-				ctx.Popup.AddPopup(fmt.Sprintf("%s Timed Out", playerName), time.Second*2)
+				ctx.Popup.AddPopup(fmt.Sprintf("%s Timed Out", m.Nick), time.Second*2)
 			}
 
-			data, _ := ctx.State.Table.Players[playerName]
+			data, _ := ctx.State.Table.Players[m.Nick]
 			data.IsMyTurn = false
 			data.IsFolded = true
-			ctx.State.Table.Players[playerName] = data
+			data.ActionTaken = "FOLD"
+			data.ActionAmount = 0
+			ctx.State.Table.Players[m.Nick] = data
 
-		case "ACOK":
+		case *unet.ActionOKMsg:
 			switch act := s.last_action.(type) {
 			case BetAction:
-				ctx.State.Table.HighBet = act.amount
-				ctx.State.Table.Pot += act.amount
+				data := ctx.State.Table.Players[ctx.State.Nickname]
+				ctx.State.Table.placeBet(ctx.State.Nickname, act.amount-data.RoundBet)
 
-				data, _ := ctx.State.Table.Players[ctx.State.Nickname]
-				data.ChipCount -= act.amount
-				ctx.State.Table.Players[ctx.State.Nickname] = data
+			case RaiseAction:
+				data := ctx.State.Table.Players[ctx.State.Nickname]
+				ctx.State.Table.placeBet(ctx.State.Nickname, act.to-data.RoundBet)
 
 			case CallAction:
 				ctx.State.Table.Pot += act.amount
 
 				data, _ := ctx.State.Table.Players[ctx.State.Nickname]
-				data.ChipCount -= act.amount
+				data.putChips(act.amount)
+				data.ActionTaken, data.ActionAmount = "CALL", act.amount
 				ctx.State.Table.Players[ctx.State.Nickname] = data
 
 			case ReadyAction:
@@ -519,55 +683,100 @@ func (s *StateInGame) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState
 			case FoldAction:
 				data, _ := ctx.State.Table.Players[ctx.State.Nickname]
 				data.IsFolded = true
+				data.ActionTaken, data.ActionAmount = "FOLD", 0
+				ctx.State.Table.Players[ctx.State.Nickname] = data
+
+			case CheckAction:
+				data, _ := ctx.State.Table.Players[ctx.State.Nickname]
+				data.ActionTaken, data.ActionAmount = "CHCK", 0
 				ctx.State.Table.Players[ctx.State.Nickname] = data
 			}
 
+			// the server only sends PACT to the others
+			switch act := s.last_action.(type) {
+			case BetAction, RaiseAction, CallAction, FoldAction, CheckAction:
+				data := ctx.State.Table.Players[ctx.State.Nickname]
+				desc := describeAction(data.ActionTaken, data.ActionAmount)
+				if raise, ok := act.(RaiseAction); ok {
+					desc = describeRaise(raise.to)
+				}
+				ctx.State.History.Add("%s %s", ctx.State.Nickname, desc)
+				ctx.Hands.Action(ctx.State.Nickname, data.ActionTaken, data.ActionAmount)
+			}
+
 			fmt.Println("Action Accepted")
 			ctx.Popup.AddPopup("Action accepted", 1*time.Second)
 
-		case "ACFL":
-			fmt.Println("Action Failed:", evt.Msg.Payload)
-			ctx.Popup.AddPopup(fmt.Sprintf("Action failed: %s", evt.Msg.Payload), 3*time.Second)
+		case *unet.ActionFailMsg:
+			fmt.Println("Action Failed:", m.Reason)
+			ctx.Popup.AddPopup(fmt.Sprintf("Action failed: %s", m.Reason), 3*time.Second)
 
-		case "NYET":
+		case *unet.NotYourTurnMsg:
 			fmt.Println("Not your turn!")
 			ctx.Popup.AddPopup("It's not your turn!", 2*time.Second)
 
-		case "PACT":
-			handlePlayerAction(ctx, evt.Msg.Payload)
+		case *unet.PlayerActionMsg:
+			handlePlayerAction(ctx, m)
 
-		case "SDWN":
-			handleShowdown(ctx, evt.Msg.Payload)
-
-		case "GLOS":
-			ctx.Popup.AddPopup("Everyone lost. Casino Won.", time.Second*3)
+		case *unet.RoomStateMsg:
+			if drifted := reconcileTable(ctx, m); drifted > 0 {
+				fmt.Printf("DFA: Room state fixed %d drifted values\n", drifted)
+			}
+			ctx.NetHandler.SendMsg(&unet.StateOKMsg{})
 
-		case "GWIN":
-			parseTypes := []unet.ParseTypes{unet.String, unet.VarInt}
-			res, _, err := unet.ParseMessage(evt.Msg.Payload, parseTypes)
+		case *unet.RoomUpdateMsg:
+			drifted, err := applyRoomUpdate(ctx, m)
 			if err != nil {
-				fmt.Println("Server sent malformed win state :(")
-			} else {
-				winner := res[0].(string)
-				winnerAmount := res[1].(int)
+				fmt.Println("DFA: Failed to apply room update:", err)
+				ctx.NetHandler.SendMsg(&unet.UpdateFailMsg{})
+				break
+			}
 
-				data, _ := ctx.State.Table.Players[winner]
-				data.ChipCount += winnerAmount
-				ctx.State.Table.Players[winner] = data
+			if drifted > 0 {
+				fmt.Printf("DFA: Room update fixed %d drifted values\n", drifted)
+			}
+			ctx.NetHandler.SendMsg(&unet.UpdateOKMsg{})
 
-				ctx.Popup.AddPopup(fmt.Sprintf("Player: %s won %d chips", winner, winnerAmount), 5*time.Second)
+		case *unet.ShowdownMsg:
+			handleShowdown(ctx, m)
+
+		case *unet.GameLostMsg:
+			ctx.State.History.Add("Everyone folded, nobody wins")
+			ctx.Hands.NobodyWon()
+			ctx.Popup.AddPopup("Everyone lost. Casino Won.", time.Second*3)
+			ctx.State.Results.Lost = true
+
+		case *unet.GameWinMsg:
+			ctx.State.History.Add("%s wins %d", m.Nick, m.Amount)
+			ctx.Hands.Collected(m.Nick, m.Amount)
+			data, _ := ctx.State.Table.Players[m.Nick]
+			data.ChipCount += m.Amount
+			ctx.State.Table.Players[m.Nick] = data
+
+			ctx.Popup.AddPopup(fmt.Sprintf("Player: %s won %d chips", m.Nick, m.Amount), 5*time.Second)
+			ctx.State.Results.AddWin(m.Nick, m.Amount)
+
+		case *unet.GameDoneMsg:
+			checkShowdown(ctx)
+			if err := ctx.Hands.Finish(ctx.State.Nickname, ctx.State.Table.Pot); err != nil {
+				fmt.Println("DFA: Failed to save hand history:", err)
 			}
 
-		case "GMDN":
 			ctx.State.Table.CommunityCards = nil
 			ctx.State.Showdown = false
 
 			ctx.State.Table.RoundPhase = ""
 			ctx.State.Table.Pot = 0
 			ctx.State.Table.HighBet = 0
+			ctx.State.Table.LastRaise = 0
 
 			// Reset player round-specific state
 			for name, player := range ctx.State.Table.Players {
+				if player.HasLeft {
+					delete(ctx.State.Table.Players, name)
+					continue
+				}
+
 				pCards := make([]Card, 0)
 				if name != ctx.State.Nickname {
 					pCards = append(pCards, Card{Hidden: true})
@@ -577,6 +786,7 @@ func (s *StateInGame) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState
 				player.IsReady = false
 				player.IsMyTurn = false
 				player.IsFolded = false
+				player.IsAllIn = false
 
 				player.Cards = pCards
 				player.RoundBet = 0
@@ -587,7 +797,7 @@ func (s *StateInGame) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState
 			}
 
 			ctx.Popup.AddPopup("Round ended. Starting new round...", time.Second*3)
-			ctx.NetHandler.SendNetMsg(unet.NetMsg{Code: "DNOK"})
+			ctx.NetHandler.SendMsg(&unet.DoneOKMsg{})
 		}
 
 	case unet.NetReconnecting:
@@ -607,12 +817,21 @@ func (s *StateInGame) HandleNetwork(ctx *ProgCtx, msg unet.NetEvent) LogicState
 
 func (s *StateInGame) Exit(ctx *ProgCtx) {
 	myData, _ := ctx.State.Table.Players[ctx.State.Nickname]
+	if s.spectating {
+		myData = s.saved
+		ctx.State.Spectating = false
+	}
 	ctx.State.Table.Players = nil
 	ctx.State.Table.CommunityCards = nil
 	ctx.State.Table.HighBet = 0
+	ctx.State.Table.LastRaise = 0
 	ctx.State.Table.Pot = 0
+	ctx.State.Table.RoundPhase = ""
+	ctx.State.Table.Seats = nil
+	ctx.State.Table.Dealer = -1
 	ctx.State.Table.Players = make(map[string]PlayerData)
 	ctx.State.Table.Players[ctx.State.Nickname] = myData
+	ctx.State.Chat.Clear()
 }
 
 func validateGameAction(ctx *ProgCtx, action string, amount string) bool {
@@ -639,15 +858,20 @@ func validateGameAction(ctx *ProgCtx, action string, amount string) bool {
 
 	switch action {
 	case "BETT":
-		// Validate bet amount
-		betAmt, err := strconv.Atoi(amount)
-		if err != nil || betAmt <= 0 {
+		if table.HighBet > 0 && !ctx.NetHandler.HasCapability(unet.CapRaise) {
+			ctx.Popup.AddPopup("This server doesn't allow raises", 2*time.Second)
+			return false
+		}
+
+		// the amount is already a VarInt
+		betAmt, ok := unet.ReadVarInt([]byte(amount))
+		if !ok || betAmt <= 0 {
 			ctx.Popup.AddPopup("Invalid bet amount", 2*time.Second)
 			return false
 		}
 
-		if betAmt > myData.ChipCount {
-			ctx.Popup.AddPopup(fmt.Sprintf("You only have %d chips", myData.ChipCount), 3*time.Second)
+		if reason := checkBet(*table, myData, int(betAmt)); reason != "" {
+			ctx.Popup.AddPopup(reason, 3*time.Second)
 			return false
 		}
 
@@ -669,252 +893,196 @@ func validateGameAction(ctx *ProgCtx, action string, amount string) bool {
 	return true
 }
 
-func handlePlayerJoined(ctx *ProgCtx, payload string) {
-	types := []unet.ParseTypes{
-		unet.String,
-		unet.VarInt,
-		unet.SmallInt,
-		unet.SmallInt,
-		unet.SmallInt,
-		unet.SmallInt,
-		unet.VarInt,
-		unet.VarInt,
-		unet.VarInt,
+func playerFromSnapshot(p unet.PlayerSnapshot) PlayerData {
+	return PlayerData{
+		ChipCount:    p.Chips,
+		RoundBet:     p.RoundBet,
+		TotalBet:     p.TotalBet,
+		Cards:        []Card{{Hidden: true}, {Hidden: true}},
+		IsMyTurn:     p.IsTurn,
+		IsFolded:     p.IsFolded,
+		IsReady:      p.IsReady,
+		IsAllIn:      p.Chips == 0 && !p.IsFolded && p.RoundBet+p.TotalBet > 0,
+		HasLeft:      p.Action == unet.ActionLeft,
+		ActionTaken:  actionIntToString(p.Action),
+		ActionAmount: p.ActionAmount,
 	}
+}
 
-	// we are parsing entire message, thus we ignore consumedBytes
-	parseResults, _, err := unet.ParseMessage(payload, types)
-	if err != nil {
-		fmt.Println("Error occured: ", err.Error())
-		return
-	}
+func handlePlayerAction(ctx *ProgCtx, m *unet.PlayerActionMsg) {
+	fmt.Println("Parsed Action: ", m.Nick, m.Action, m.Amount)
 
-	pNick := parseResults[0].(string)
-	pChips := parseResults[1].(int)
-	pIsFolded := parseResults[2].(int)
-	pIsReady := parseResults[3].(int)
-	pIsMyTurn := parseResults[4].(int)
-	pActionTaken := parseResults[5].(int)
-	pActionAmount := parseResults[6].(int)
-	pRoundBet := parseResults[7].(int)
-	pTotalBet := parseResults[8].(int)
-
-	pCards := make([]Card, 0)
-	pCards = append(pCards, Card{Hidden: true})
-	pCards = append(pCards, Card{Hidden: true})
-
-	ctx.State.Table.Players[pNick] = PlayerData{
-		ChipCount:    pChips,
-		RoundBet:     pRoundBet,
-		TotalBet:     pTotalBet,
-		Cards:        pCards,
-		IsMyTurn:     pIsMyTurn == 1,
-		IsFolded:     pIsFolded == 1,
-		IsReady:      pIsReady == 1,
-		ActionTaken:  actionIntToString(pActionTaken),
-		ActionAmount: pActionAmount,
-	}
-}
-
-func handlePlayerAction(ctx *ProgCtx, payload string) {
-	parseTypes := []unet.ParseTypes{
-		unet.String,
-		unet.SmallInt,
-		unet.VarInt,
-	}
-	res, _, err := unet.ParseMessage(payload, parseTypes)
-	if err != nil {
-		// here a state desync will happen, maybe it would be better to disconnect at this point for resync
+	player, exists := ctx.State.Table.Players[m.Nick]
+	if !exists {
 		return
 	}
 
-	pNick := res[0].(string)
-	pActionTaken := res[1].(int)
-	pActionAmount := res[2].(int)
-	fmt.Println("Parsed Action: ", pNick, pActionTaken, pActionAmount)
-
-	player, exists := ctx.State.Table.Players[pNick]
-	if !exists {
+	player.ActionTaken = actionIntToString(m.Action)
+	player.ActionAmount = m.Amount
+	ctx.Hands.Action(m.Nick, player.ActionTaken, player.ActionAmount)
+
+	// a bet or raise is booked on the table itself
+	if player.ActionTaken == "BETT" {
+		ctx.State.Table.Players[m.Nick] = player
+		if ctx.State.Table.placeBet(m.Nick, m.Amount) {
+			to := ctx.State.Table.Players[m.Nick].RoundBet
+			ctx.State.History.Add("%s %s", m.Nick, describeRaise(to))
+			ctx.Popup.AddPopup(fmt.Sprintf("%s raised to %d", m.Nick, to), 2*time.Second)
+		} else {
+			ctx.State.History.Add("%s %s", m.Nick, describeAction(player.ActionTaken, player.ActionAmount))
+			ctx.Popup.AddPopup(fmt.Sprintf("%s bet %d", m.Nick, m.Amount), 2*time.Second)
+		}
 		return
 	}
 
-	player.ActionTaken = actionIntToString(pActionTaken)
-	player.ActionAmount = pActionAmount
+	ctx.State.History.Add("%s %s", m.Nick, describeAction(player.ActionTaken, player.ActionAmount))
 
 	switch player.ActionTaken {
-	case "BETT":
-		player.RoundBet += player.ActionAmount
-		player.ChipCount -= player.ActionAmount
-		ctx.State.Table.HighBet = player.RoundBet
-		ctx.State.Table.Pot += player.RoundBet
-		ctx.Popup.AddPopup(fmt.Sprintf("%s bet %d", pNick, player.ActionAmount), 2*time.Second)
 	case "CALL":
-		player.RoundBet = pActionAmount
-		ctx.State.Table.Pot += pActionAmount
-		player.ChipCount -= pActionAmount
-		ctx.Popup.AddPopup(fmt.Sprintf("%s called %d", pNick, pActionAmount), 2*time.Second)
+		player.putChips(m.Amount)
+		ctx.State.Table.Pot += m.Amount
+		ctx.Popup.AddPopup(fmt.Sprintf("%s called %d", m.Nick, m.Amount), 2*time.Second)
 	case "FOLD":
 		player.IsFolded = true
-		ctx.Popup.AddPopup(fmt.Sprintf("%s folded", pNick), 2*time.Second)
+		ctx.Popup.AddPopup(fmt.Sprintf("%s folded", m.Nick), 2*time.Second)
 	case "CHCK":
-		ctx.Popup.AddPopup(fmt.Sprintf("%s checked", pNick), 2*time.Second)
+		ctx.Popup.AddPopup(fmt.Sprintf("%s checked", m.Nick), 2*time.Second)
 	case "LEFT":
-		ctx.Popup.AddPopup(fmt.Sprintf("%s left", pNick), 2*time.Second)
-		delete(ctx.State.Table.Players, pNick)
-		return
+		ctx.Popup.AddPopup(fmt.Sprintf("%s left", m.Nick), 2*time.Second)
+		// between hands the server frees the seat right away
+		if ctx.State.Table.RoundPhase == "" {
+			delete(ctx.State.Table.Players, m.Nick)
+			return
+		}
+		player.HasLeft = true
+		player.IsMyTurn = false
 	}
 
-	ctx.State.Table.Players[pNick] = player
+	ctx.State.Table.Players[m.Nick] = player
 }
 
-func handleShowdown(ctx *ProgCtx, payload string) {
-	pCount, _ := unet.ReadSmallInt([]byte(payload))
-	parseTypes := []unet.ParseTypes{
-		unet.String,
-		unet.SmallInt,
-		unet.SmallInt,
+func handleShowdown(ctx *ProgCtx, m *unet.ShowdownMsg) {
+	for _, hand := range m.Hands {
+		pData, exists := ctx.State.Table.Players[hand.Nick]
+		if !exists || len(pData.Cards) < 2 {
+			continue
+		}
+		pData.Cards[0] = Card{Hidden: false, ID: hand.Card1, Symbol: TranslateCardID(hand.Card1)}
+		pData.Cards[1] = Card{Hidden: false, ID: hand.Card2, Symbol: TranslateCardID(hand.Card2)}
 	}
 
-	nextPayload := string([]byte(payload[2:]))
-	for range pCount {
-		res, consumed, err := unet.ParseMessage(nextPayload, parseTypes)
-		if err != nil {
+	ctx.State.History.StartRound("Showdown")
+	for _, hand := range m.Hands {
+		pData, exists := ctx.State.Table.Players[hand.Nick]
+		if !exists || pData.IsFolded {
 			continue
 		}
 
-		nextPayload = string([]byte(nextPayload[consumed:]))
-
-		pNick := res[0].(string)
-		pC1 := res[1].(int)
-		pC2 := res[2].(int)
-
-		pData, exists := ctx.State.Table.Players[pNick]
-		if !exists {
-			continue
+		shown := fmt.Sprintf("%s shows %s, %s", hand.Nick, TranslateCardID(hand.Card1), TranslateCardID(hand.Card2))
+		if desc := describeHand(pData.Cards, ctx.State.Table.CommunityCards); desc != "" {
+			shown += " (" + desc + ")"
 		}
-		pData.Cards[0] = Card{Hidden: false, ID: pC1, Symbol: TranslateCardID(pC1)}
-		pData.Cards[1] = Card{Hidden: false, ID: pC2, Symbol: TranslateCardID(pC2)}
+		ctx.State.History.Add("%s", shown)
 	}
 
+	ctx.Hands.Showdown(&ctx.State.Table)
+
 	ctx.State.Showdown = true
+	ctx.State.Results.Begin()
 	ctx.Popup.AddPopup("Showdown! Revealing cards...", 3*time.Second)
 }
 
-func deserializeRoomState(ctx *ProgCtx, payload string) error {
+func applyRoomState(ctx *ProgCtx, m *unet.RoomStateMsg) {
 	if ctx.State.Table.Players == nil {
 		ctx.State.Table.Players = make(map[string]PlayerData)
 	}
 
-	ctx.State.Table.CommunityCards = make([]Card, 0)
+	ctx.State.Table.Pot = m.Pot
+	ctx.State.Table.HighBet = m.HighBet
+	applySeats(&ctx.State.Table, m)
 
-	readTypes := []unet.ParseTypes{
-		unet.VarInt,
-		unet.VarInt,
-		unet.SmallInt,
-		unet.SmallInt,
-		unet.SmallInt,
-		unet.SmallInt,
+	// came in during a hand, eg. spectating or after a reconnect
+	if ctx.State.Table.RoundPhase == "" && (m.Pot > 0 || m.CardsDealt || len(m.CommunityCards) > 0) {
+		ctx.State.Table.RoundPhase = roundName(len(m.CommunityCards))
 	}
 
-	res, consumed, err := unet.ParseMessage(payload, readTypes)
-	if err != nil {
-		return errors.Join(err, fmt.Errorf("Error occured during 1st phase"))
+	ctx.State.Table.CommunityCards = make([]Card, 0, len(m.CommunityCards))
+	for _, cardID := range m.CommunityCards {
+		ctx.State.Table.CommunityCards = append(ctx.State.Table.CommunityCards, Card{
+			ID:     cardID,
+			Symbol: TranslateCardID(cardID),
+		})
 	}
 
-	nextPayload := string([]byte(payload[consumed:]))
-
-	ctx.State.Table.Pot = res[0].(int)
-	ctx.State.Table.HighBet = res[1].(int)
-	cardsDealt := res[2].(int) == 1
-	card1 := res[3].(int)
-	card2 := res[4].(int)
-	commCount := res[5].(int)
+	for _, p := range m.Players {
+		pData := playerFromSnapshot(p)
 
-	readCardTypes := make([]unet.ParseTypes, commCount+1)
-	for i := range readCardTypes {
-		readCardTypes[i] = unet.SmallInt
-	}
+		if p.Nick == ctx.State.Nickname && m.CardsDealt {
+			pData.Cards[0] = Card{ID: m.Card1, Symbol: TranslateCardID(m.Card1), Hidden: false}
+			pData.Cards[1] = Card{ID: m.Card2, Symbol: TranslateCardID(m.Card2), Hidden: false}
+		}
 
-	res, consumed, err = unet.ParseMessage(nextPayload, readCardTypes)
-	if err != nil {
-		return errors.Join(err, fmt.Errorf("Error occured during reading CommunityCards and PlayerCount"))
+		ctx.State.Table.Players[p.Nick] = pData
 	}
 
-	nextPayload = string([]byte(nextPayload[consumed:]))
-
-	for index := range commCount {
-		cardID := res[index].(int)
-		ctx.State.Table.CommunityCards = append(ctx.State.Table.CommunityCards, Card{
-			ID:     cardID,
-			Symbol: TranslateCardID(cardID),
-		})
-	}
+	fmt.Println(ctx.State.Table.Players)
+}
 
-	playerCount := res[commCount].(int)
+// applyLobbyUpdate changes one room of the list, only rooms from the last RMRQ are known
+func applyLobbyUpdate(ctx *ProgCtx, m *unet.RoomUpdateMsg) error {
+	ctx.StateMutex.Lock()
+	defer ctx.StateMutex.Unlock()
 
-	readPlayerTypes := []unet.ParseTypes{
-		unet.String,
-		unet.VarInt,
-		unet.SmallInt,
-		unet.SmallInt,
-		unet.SmallInt,
-		unet.SmallInt,
-		unet.VarInt,
-		unet.VarInt,
-		unet.VarInt,
+	room, exists := ctx.State.Rooms[m.RoomID]
+	if !exists {
+		return fmt.Errorf("unknown room %d", m.RoomID)
 	}
 
-	for range playerCount {
-		res, consumed, err := unet.ParseMessage(nextPayload, readPlayerTypes)
-		if err != nil {
-			return errors.Join(err, fmt.Errorf("Error occured during reading player"))
+	switch m.MemberID {
+	case unet.UpdateRoomName:
+		update := unet.RoomNameUpdate{}
+		if err := m.DecodeValue(&update); err != nil {
+			return err
 		}
+		room.Name = update.Name
 
-		nextPayload = string([]byte(nextPayload[consumed:]))
-
-		pNick := res[0].(string)
-		pChips := res[1].(int)
-		pIsFolded := res[2].(int)
-		pIsReady := res[3].(int)
-		pIsMyTurn := res[4].(int)
-		pActionTaken := res[5].(int)
-		pActionAmount := res[6].(int)
-		pRoundBet := res[7].(int)
-		pTotalBet := res[8].(int)
-
-		pCards := make([]Card, 0)
-		pCards = append(pCards, Card{Hidden: true})
-		pCards = append(pCards, Card{Hidden: true})
-
-		pData := PlayerData{
-			RoundBet:     pRoundBet,
-			TotalBet:     pTotalBet,
-			ChipCount:    pChips,
-			IsFolded:     pIsFolded == 1,
-			IsReady:      pIsReady == 1,
-			IsMyTurn:     pIsMyTurn == 1,
-			ActionTaken:  actionIntToString(pActionTaken),
-			ActionAmount: pActionAmount,
-			Cards:        pCards,
+	case unet.UpdateRoomPlayers:
+		update := unet.RoomCountUpdate{}
+		if err := m.DecodeValue(&update); err != nil {
+			return err
 		}
+		room.CurrentPlayers = update.Count
 
-		if pNick == ctx.State.Nickname && cardsDealt {
-			pData.Cards[0] = Card{ID: card1, Symbol: TranslateCardID(card1), Hidden: false}
-			pData.Cards[1] = Card{ID: card2, Symbol: TranslateCardID(card2), Hidden: false}
+	case unet.UpdateRoomMax:
+		update := unet.RoomCountUpdate{}
+		if err := m.DecodeValue(&update); err != nil {
+			return err
 		}
+		room.MaxPlayers = update.Count
 
-		ctx.State.Table.Players[pNick] = pData
+	default:
+		return fmt.Errorf("room update member %d isn't a lobby one", m.MemberID)
 	}
 
-	fmt.Println(ctx.State.Table.Players)
-
+	fmt.Printf("GameThread: Room %d updated: %d/%d %s\n", room.ID, room.CurrentPlayers, room.MaxPlayers, room.Name)
+	ctx.State.Rooms[m.RoomID] = room
 	return nil
 }
 
-func handleRoomData(ctx *ProgCtx, msg unet.NetMsg) error {
-	room := deserializeRoom(msg.Payload)
-	if room.ID == -1 {
-		return fmt.Errorf("invalid room")
+// currentRoomName falls back to the id when the room list didn't have it, eg. after a reconnect
+func currentRoomName(ctx *ProgCtx) string {
+	if room, exists := ctx.State.Rooms[ctx.State.RoomID]; exists {
+		return room.Name
+	}
+	return fmt.Sprintf("Room %d", ctx.State.RoomID)
+}
+
+func handleRoomData(ctx *ProgCtx, m *unet.RoomMsg) {
+	room := Room{
+		ID:             m.ID,
+		Name:           m.Name,
+		CurrentPlayers: m.CurrentPlayers,
+		MaxPlayers:     m.MaxPlayers,
 	}
 
 	fmt.Printf("GameThread: Received Room: ID=%d, Name=%s\n", room.ID, room.Name)
@@ -924,53 +1092,4 @@ func handleRoomData(ctx *ProgCtx, msg unet.NetMsg) error {
 	}
 	ctx.State.Rooms[room.ID] = room
 	ctx.StateMutex.Unlock()
-	return nil
-}
-
-func deserializeRoom(payload string) Room {
-	err_room := Room{ID: -1, Name: "Invalid Room Data"}
-	valid_room := Room{}
-
-	offset := 0
-	byte_payload := []byte(payload[offset:])
-
-	id, ok := unet.ReadBigInt(byte_payload)
-
-	if !ok {
-		return err_room
-	} else {
-		valid_room.ID = id
-	}
-
-	offset += 4
-	name, ok := unet.ReadString(byte_payload[offset:])
-
-	if !ok {
-		return err_room
-	} else {
-		valid_room.Name = name
-	}
-
-	// 4 bytes for len + len(name)
-	nameLen, _ := unet.ReadBigInt(byte_payload[4:]) // Re-read len to calculate offset
-	offset += 4 + nameLen
-
-	curr_players, ok := unet.ReadSmallInt(byte_payload[offset:])
-
-	if !ok {
-		return err_room
-	} else {
-		valid_room.CurrentPlayers = curr_players
-	}
-
-	offset += 2
-	max_players, ok := unet.ReadSmallInt(byte_payload[offset:])
-
-	if !ok {
-		return err_room
-	} else {
-		valid_room.MaxPlayers = max_players
-	}
-
-	return valid_room
 }
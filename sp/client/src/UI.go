@@ -2,9 +2,11 @@ package main
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 
+	unet "poker-client/ups_net"
 	w "poker-client/window"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
@@ -33,7 +35,7 @@ func buildMainMenu(ctx *ProgCtx) UIElement {
 
 	chipsField := w.NewHStack(5)
 	chipsLabel := w.NewLabelComponent("Chips:", 20, rl.White)
-	chipsTextBox := buildCenteredTextBox("MainMenu_NickBox", &ctx.State.ChipsStr, 100)
+	chipsTextBox := buildCenteredTextBox("MainMenu_ChipsBox", &ctx.State.ChipsStr, 100)
 	chipsField.AddChild(chipsLabel)
 	chipsField.AddChild(chipsTextBox)
 
@@ -66,10 +68,12 @@ func buildMainMenu(ctx *ProgCtx) UIElement {
 	horPSCentered := w.NewCenterComponent(horPS)
 
 	connect_btn := w.NewCenterComponent(w.NewButtonComponent("MainMenu_ConnectBtn", "Connect", 150, 50))
+	servers_btn := w.NewCenterComponent(w.NewButtonComponent("MainMenu_ServersBtn", "Servers", 150, 50))
 	close_btn := w.NewCenterComponent(w.NewButtonComponent("MainMenu_CloseBtn", "Close", 150, 50))
 
 	mainMenu.AddChild(horPSCentered)
 	mainMenu.AddChild(connect_btn)
+	mainMenu.AddChild(servers_btn)
 	mainMenu.AddChild(close_btn)
 
 	mainMenuPanel := w.NewPanelComponent(rl.DarkGray, mainMenu)
@@ -131,59 +135,65 @@ func buildGameScreen(ctx *ProgCtx) UIElement {
 
 	screen.ResetRiver()
 	for _, card := range ctx.State.Table.CommunityCards {
-		screen.AddRiverCard(buildCardComponent(card.Symbol, rl.RayWhite))
+		screen.AddRiverCard(buildCardComponent(card, false))
 	}
 
-	screen.ResetOtherPlayers()
-
-	// Sort players by name for consistent display
-	var playerNames []string
-	for name := range ctx.State.Table.Players {
-		if name != ctx.State.Nickname {
-			playerNames = append(playerNames, name)
-		}
+	// seats as the server has them, the local player at the bottom
+	seats := ctx.State.Table.seating()
+	seatCount := max(len(seats), ctx.State.Rooms[ctx.State.RoomID].MaxPlayers)
+	localSeat := -1
+	if !ctx.State.Spectating {
+		localSeat = slices.Index(seats, ctx.State.Nickname)
 	}
-	sort.Strings(playerNames)
-
-	for _, name := range playerNames {
-		player := ctx.State.Table.Players[name]
-		info := w.NewPlayerInfoComponent(player.IsMyTurn)
-		info.AddDesc(w.NewLabelComponent(name, 12, rl.White))
-		info.AddDesc(w.NewLabelComponent(fmt.Sprintf("Chips: %d", player.ChipCount), 12, rl.Yellow))
-		if player.TotalBet > 0 {
-			info.AddDesc(w.NewLabelComponent(fmt.Sprintf("Total Bet: %d", player.TotalBet), 12, rl.Orange))
-		}
+	table := w.NewTableLayout(seatCount, localSeat)
 
-		// Show status if not active
-		if player.ActionTaken != "NONE" {
-			info.AddDesc(w.NewLabelComponent(fmt.Sprintf("%s %d", player.ActionTaken, player.ActionAmount), 12, rl.White))
-		} else if player.IsFolded {
-			info.AddDesc(w.NewLabelComponent("Folded", 12, rl.White))
-		} else if player.IsReady {
-			info.AddDesc(w.NewLabelComponent("Ready", 12, rl.White))
+	for idx, name := range seats {
+		if name == "" || idx == localSeat {
+			continue
 		}
+		table.SetSeat(idx, buildPlayerInfo(ctx, name))
+	}
 
-		for _, c := range player.Cards {
-			if c.Hidden {
-				info.AddCard(buildHiddenCardComponent())
-			} else {
-				info.AddCard(buildCardComponent(c.Symbol, rl.RayWhite))
-			}
+	// the button moves at the start of a hand, between hands it would point at the last one
+	if ctx.State.Table.RoundPhase != "" && ctx.State.Table.Dealer >= 0 {
+		table.AddMarker(ctx.State.Table.Dealer, "D")
+		if small, big := blindSeats(ctx.State.Table); small >= 0 {
+			table.AddMarker(small, "SB")
+			table.AddMarker(big, "BB")
 		}
-		screen.AddOtherPlayer(info)
 	}
+	screen.SetTable(table)
 
 	myData, _ := ctx.State.Table.Players[ctx.State.Nickname]
 
 	pot := w.NewPotDisplayComponent(ctx.State.Table.Pot, ctx.State.Table.HighBet, myData.ChipCount)
+	pot.Spectating = ctx.State.Spectating
+	for i, tablePot := range computePots(ctx.State.Table) {
+		name := "Main pot"
+		if i > 0 {
+			name = fmt.Sprintf("Side pot %d", i)
+		}
+		pot.AddPot(name, tablePot.Amount, tablePot.Eligible)
+	}
 	screen.SetPotDisplay(pot)
+	screen.SetHistory(buildHistoryComponent(&ctx.State.History))
+	if ctx.NetHandler.HasCapability(unet.CapChat) {
+		screen.SetChat(buildChatComponent(&ctx.State.Chat))
+	}
 
 	for _, card := range myData.Cards {
-		if card.Hidden {
-			screen.AddPlayerCard(buildHiddenCardComponent())
-		} else {
-			screen.AddPlayerCard(buildCardComponent(card.Symbol, rl.Gold))
-		}
+		screen.AddPlayerCard(buildCardComponent(card, true))
+	}
+
+	if desc := describeHand(myData.Cards, ctx.State.Table.CommunityCards); desc != "" {
+		screen.AddPlayerCard(w.NewCenterComponent(w.NewLabelComponent(desc, 20, rl.SkyBlue)))
+	}
+
+	// spectators only get to leave
+	if ctx.State.Spectating {
+		screen.AddPlayerCard(w.NewCenterComponent(w.NewLabelComponent("Spectating", 20, rl.LightGray)))
+		screen.AddActionButton(w.NewButtonComponent("Game_Leave", "Leave", 100, 50))
+		return UIElement{dirty: true, component: screenPanel}
 	}
 
 	showActions := myData.IsMyTurn && !myData.IsFolded
@@ -202,17 +212,22 @@ func buildGameScreen(ctx *ProgCtx) UIElement {
 				checkBtn := w.NewButtonComponent("Game_Check", "Check", 100, 50)
 				screen.AddActionButton(checkBtn)
 
-				betStack := w.NewHStack(0)
-				betBox := w.NewTextBoxComponent("Game_BetAmount", &ctx.State.BetAmount, 6)
-				betBtn := w.NewButtonComponent("Game_Bet", "Bet", 100, 50)
-				betStack.AddChild(betBtn)
-				betStack.AddChild(betBox)
-				screen.AddActionButton(betStack)
+				screen.AddActionButton(buildBetControls(ctx, myData))
 			}
 
 			if ctx.State.Table.HighBet > 0 {
-				callBtn := w.NewButtonComponent("Game_Call", fmt.Sprintf("Call %d", ctx.State.Table.HighBet), 100, 50)
+				callAmount := min(myData.ChipCount, ctx.State.Table.HighBet-myData.RoundBet)
+				callText := fmt.Sprintf("Call %d", callAmount)
+				if callAmount == myData.ChipCount {
+					callText = fmt.Sprintf("All-in %d", callAmount)
+				}
+				callBtn := w.NewButtonComponent("Game_Call", callText, 100, 50)
 				screen.AddActionButton(callBtn)
+
+				// nothing left to raise with once the call takes everything
+				if ctx.NetHandler.HasCapability(unet.CapRaise) && myData.ChipCount > callAmount {
+					screen.AddActionButton(buildBetControls(ctx, myData))
+				}
 			}
 
 			foldBtn := w.NewButtonComponent("Game_Fold", "Fold", 100, 50)
@@ -226,14 +241,16 @@ func buildGameScreen(ctx *ProgCtx) UIElement {
 	return UIElement{dirty: true, component: screenPanel}
 }
 
-func buildCardComponent(text string, color rl.Color) w.RGComponent {
-	lbl := w.NewCenterComponent(w.NewLabelComponent(text, 20, rl.Black))
-	return w.NewPanelComponent(color, lbl)
-}
+// buildCardComponent shows hidden cards face down, highlight marks the own hand
+func buildCardComponent(card Card, highlight bool) w.RGComponent {
+	id := card.ID
+	if card.Hidden {
+		id = w.CardBack
+	}
 
-func buildHiddenCardComponent() w.RGComponent {
-	lbl := w.NewCenterComponent(w.NewLabelComponent("??", 20, rl.White))
-	return w.NewPanelComponent(rl.Red, lbl)
+	comp := w.NewCardComponent(id)
+	comp.Highlight = highlight
+	return comp
 }
 
 func buildRoomSelectUI(ctx *ProgCtx) UIElement {
@@ -263,8 +280,12 @@ func buildRoomSelectUI(ctx *ProgCtx) UIElement {
 
 	for _, room := range rooms {
 		roomText := fmt.Sprintf("%s (%d/%d)", room.Name, room.CurrentPlayers, room.MaxPlayers)
-		centered_btn := w.NewCenterComponent(w.NewButtonComponent("join_"+strconv.Itoa(room.ID), roomText, 150, 50))
-		roomList.AddChild(centered_btn)
+		roomRow := w.NewHStack(10)
+		roomRow.AddChild(w.NewCenterComponent(w.NewButtonComponent("join_"+strconv.Itoa(room.ID), roomText, 150, 50)))
+		if ctx.NetHandler.HasCapability(unet.CapSpectate) {
+			roomRow.AddChild(w.NewCenterComponent(w.NewButtonComponent("spectate_"+strconv.Itoa(room.ID), "Watch", 100, 50)))
+		}
+		roomList.AddChild(roomRow)
 	}
 
 	backBtn := w.NewCenterComponent(w.NewButtonComponent("RoomSelect_BackBtn", "Back", 150, 50))
@@ -275,7 +296,77 @@ func buildRoomSelectUI(ctx *ProgCtx) UIElement {
 	roomList.AddChild(buttonStack)
 
 	roomListPanel := w.NewPanelComponent(rl.DarkBlue, roomList)
-	roomBoundsBox := w.NewBoundsBox(0.4, 0.6, roomListPanel)
+	if !ctx.NetHandler.HasCapability(unet.CapChat) {
+		return UIElement{dirty: true, component: w.NewBoundsBox(0.4, 0.6, roomListPanel)}
+	}
+
+	// the lobby chat goes next to the list
+	ctx.StateMutex.RLock()
+	chat := buildChatComponent(&ctx.State.Chat)
+	ctx.StateMutex.RUnlock()
 
-	return UIElement{dirty: true, component: roomBoundsBox}
+	lobby := w.NewHStack(10)
+	lobby.AddChild(roomListPanel)
+	lobby.AddChild(chat)
+
+	return UIElement{dirty: true, component: w.NewBoundsBox(0.8, 0.6, lobby)}
+}
+
+// buildBetControls is the Bet or Raise button with the amount box, the slider
+// and the pot presets over them
+func buildBetControls(ctx *ProgCtx, me PlayerData) w.RGComponent {
+	minTo, maxTo := betLimits(ctx.State.Table, me)
+
+	betText := "Bet"
+	if ctx.State.Table.HighBet > 0 {
+		betText = fmt.Sprintf("Raise to %d", min(max(ctx.State.BetSize, minTo), maxTo))
+	}
+
+	presets := w.NewHStack(2)
+	for _, preset := range betPresets {
+		presets.AddChild(w.NewButtonComponent(preset.id, preset.label, 80, 24))
+	}
+
+	betRow := w.NewHStack(2)
+	betRow.AddChild(w.NewButtonComponent("Game_Bet", betText, 120, 40))
+	betRow.AddChild(w.NewTextBoxComponent("Game_BetAmount", &ctx.State.BetAmount, 6))
+	betRow.AddChild(w.NewSliderComponent("Game_BetSlider", &ctx.State.BetSize, min(minTo, maxTo), maxTo, 16))
+
+	controls := w.NewVStack(0)
+	controls.AddChild(presets)
+	controls.AddChild(betRow)
+	return controls
+}
+
+// buildPlayerInfo is the seat box of another player
+func buildPlayerInfo(ctx *ProgCtx, name string) w.RGComponent {
+	player := ctx.State.Table.Players[name]
+	info := w.NewPlayerInfoComponent(player.IsMyTurn)
+	info.AddDesc(w.NewLabelComponent(name, 12, rl.White))
+	info.AddDesc(w.NewLabelComponent(fmt.Sprintf("Chips: %d", player.ChipCount), 12, rl.Yellow))
+	if player.TotalBet > 0 {
+		info.AddDesc(w.NewLabelComponent(fmt.Sprintf("Total Bet: %d", player.TotalBet), 12, rl.Orange))
+	}
+
+	// Show status if not active
+	if player.ActionTaken != "NONE" {
+		info.AddDesc(w.NewLabelComponent(fmt.Sprintf("%s %d", player.ActionTaken, player.ActionAmount), 12, rl.White))
+	} else if player.IsFolded {
+		info.AddDesc(w.NewLabelComponent("Folded", 12, rl.White))
+	} else if player.IsReady {
+		info.AddDesc(w.NewLabelComponent("Ready", 12, rl.White))
+	}
+
+	if player.IsAllIn {
+		info.AddDesc(w.NewLabelComponent("All-in", 12, rl.Red))
+	}
+
+	if desc := describeHand(player.Cards, ctx.State.Table.CommunityCards); desc != "" && !player.IsFolded {
+		info.AddDesc(w.NewLabelComponent(desc, 12, rl.SkyBlue))
+	}
+
+	for _, c := range player.Cards {
+		info.AddCard(buildCardComponent(c, false))
+	}
+	return info
 }
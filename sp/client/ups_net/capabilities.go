@@ -0,0 +1,50 @@
+package ups_net
+
+import "slices"
+
+// Version of the protocol this client speaks.
+// A server that doesn't answer with a version is treated as LegacyVersion.
+const (
+	LegacyVersion   = 0
+	ProtocolVersion = 1
+)
+
+// Optional features, advertised by the client in CONN and confirmed by the server in PNOK.
+// A feature may only be used if the server put it into its answer.
+const (
+	CapExtendedFrames = "XFRM"
+	CapChat           = "CHAT"
+	CapRaise          = "RAIS" // BETT over the high bet raises
+	CapSpectate       = "SPEC" // watching rooms, servers without it drop the connection on SPEC
+)
+
+// SupportedCapabilities is everything this client can handle
+var SupportedCapabilities = []string{
+	CapExtendedFrames,
+	CapChat,
+	CapRaise,
+	CapSpectate,
+}
+
+// ServerInfo is what the server agreed to during the handshake
+type ServerInfo struct {
+	Version      int
+	Capabilities []string
+}
+
+func (si ServerInfo) Has(capability string) bool {
+	return slices.Contains(si.Capabilities, capability)
+}
+
+// the server must only confirm what was offered, anything else is dropped
+func negotiate(version int, capabilities []string) ServerInfo {
+	info := ServerInfo{Version: version}
+
+	for _, capability := range capabilities {
+		if slices.Contains(SupportedCapabilities, capability) {
+			info.Capabilities = append(info.Capabilities, capability)
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,122 @@
+package ups_net
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Direction string
+
+const (
+	DirIn  Direction = "IN"
+	DirOut Direction = "OUT"
+)
+
+var ErrBadCapture = errors.New("bad capture line")
+
+// CaptureEntry is one chunk of wire traffic. Inbound entries are the raw
+// reads, so a frame may be split over several of them or share one.
+type CaptureEntry struct {
+	Time time.Time
+	Dir  Direction
+	Data []byte
+}
+
+// Capture writes wire traffic to a text file, one entry per line:
+//
+//	2026-01-02T15:04:05.000000000Z IN "PKRNALV!\n"
+type Capture struct {
+	mtx  sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+func NewCapture(path string) (*Capture, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Capture{file: file, w: bufio.NewWriter(file)}
+	fmt.Fprintf(c.w, "# PKR capture started %s\n", time.Now().Format(time.RFC3339))
+	return c, c.w.Flush()
+}
+
+// Record is safe to call on a nil capture, nothing is written then
+func (c *Capture) Record(dir Direction, data []byte) {
+	if c == nil {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	// flushed right away, the interesting sessions are the ones that crash
+	fmt.Fprintf(c.w, "%s %s %s\n", time.Now().UTC().Format(time.RFC3339Nano), dir, strconv.Quote(string(data)))
+	if err := c.w.Flush(); err != nil {
+		fmt.Println("Capture write failed:", err)
+	}
+}
+
+func (c *Capture) Close() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.w.Flush()
+	return c.file.Close()
+}
+
+// ReadCapture parses what Capture wrote, empty and # lines are skipped
+func ReadCapture(r io.Reader) ([]CaptureEntry, error) {
+	entries := make([]CaptureEntry, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*int(MAX_EXT_PAYLOAD_LEN))
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		stamp, rest, ok1 := strings.Cut(line, " ")
+		dir, quoted, ok2 := strings.Cut(rest, " ")
+		if !ok1 || !ok2 || (Direction(dir) != DirIn && Direction(dir) != DirOut) {
+			return nil, fmt.Errorf("%w %d", ErrBadCapture, lineNum)
+		}
+
+		at, err := time.Parse(time.RFC3339Nano, stamp)
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: %v", ErrBadCapture, lineNum, err)
+		}
+
+		data, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: %v", ErrBadCapture, lineNum, err)
+		}
+
+		entries = append(entries, CaptureEntry{Time: at, Dir: Direction(dir), Data: []byte(data)})
+	}
+
+	return entries, scanner.Err()
+}
+
+func LoadCapture(path string) ([]CaptureEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadCapture(file)
+}
@@ -0,0 +1,48 @@
+package ups_net
+
+import "time"
+
+// Chat limits, the client checks them before sending and the server
+// answers CHFL to anything over them
+const (
+	ChatBurst  = 5 // messages allowed within ChatWindow
+	ChatWindow = 10 * time.Second
+)
+
+// ChatFits is whether text can be said by nick. WriteString takes 9999 bytes
+// at most and CHMS carries both strings, so they share one normal frame.
+func ChatFits(nick, text string) bool {
+	nickStr, ok := WriteString(nick)
+	if !ok {
+		return false
+	}
+
+	textStr, ok := WriteString(text)
+	if !ok {
+		return false
+	}
+
+	return len(nickStr)+len(textStr) <= maxPayloadLen
+}
+
+// ChatLimiter allows ChatBurst messages in any ChatWindow
+type ChatLimiter struct {
+	sent []time.Time
+}
+
+func (l *ChatLimiter) Allow(now time.Time) bool {
+	recent := l.sent[:0]
+	for _, at := range l.sent {
+		if now.Sub(at) < ChatWindow {
+			recent = append(recent, at)
+		}
+	}
+	l.sent = recent
+
+	if len(l.sent) >= ChatBurst {
+		return false
+	}
+
+	l.sent = append(l.sent, now)
+	return true
+}
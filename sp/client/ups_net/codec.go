@@ -0,0 +1,351 @@
+package ups_net
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Struct tags understood by Marshal/Unmarshal:
+//
+//	pkr:"smallint"                         int or bool (01/00)
+//	pkr:"bigint"                           int
+//	pkr:"varint"                           int
+//	pkr:"string"                           string
+//	pkr:"raw"                              string, takes the rest of the payload
+//	pkr:"struct"                           nested tagged struct
+//	pkr:"list,count=smallint"              slice of tagged structs
+//	pkr:"list,count=smallint,elem=varint"  slice of basic values
+//
+// Any of them can have ",optional" appended. An optional field missing at the
+// end of the payload keeps its zero value, which lets new fields be added
+// without breaking peers that don't send them yet. Optional fields at the end
+// that hold their zero value are not written either.
+// Fields without a pkr tag are not part of the payload.
+
+type fieldSpec struct {
+	kind     string
+	count    string
+	elem     string
+	optional bool
+}
+
+func parseTag(tag string) (fieldSpec, error) {
+	parts := strings.Split(tag, ",")
+	spec := fieldSpec{kind: parts[0]}
+
+	for _, opt := range parts[1:] {
+		if opt == "optional" {
+			spec.optional = true
+			continue
+		}
+
+		key, value, found := strings.Cut(opt, "=")
+		if !found {
+			return spec, fmt.Errorf("malformed pkr tag option %q", opt)
+		}
+
+		switch key {
+		case "count":
+			spec.count = value
+		case "elem":
+			spec.elem = value
+		default:
+			return spec, fmt.Errorf("unknown pkr tag option %q", key)
+		}
+	}
+
+	if spec.kind == "list" && spec.count == "" {
+		return spec, fmt.Errorf("list tag needs a count type")
+	}
+
+	return spec, nil
+}
+
+// Marshal encodes a tagged message struct into a NetMsg, v has to provide Code()
+func Marshal(v any) (NetMsg, error) {
+	coded, ok := v.(interface{ Code() string })
+	if !ok {
+		return NetMsg{}, fmt.Errorf("%T has no Code() method", v)
+	}
+
+	payload, err := marshalPayload(v)
+	if err != nil {
+		return NetMsg{}, fmt.Errorf("marshal %s: %w", coded.Code(), err)
+	}
+
+	return NetMsg{Code: coded.Code(), Payload: payload}, nil
+}
+
+// Unmarshal decodes the payload of msg into the tagged struct pointed to by v
+func Unmarshal(msg NetMsg, v any) error {
+	if coded, ok := v.(interface{ Code() string }); ok && coded.Code() != msg.Code {
+		return fmt.Errorf("code mismatch: message is %s, target is %s", msg.Code, coded.Code())
+	}
+
+	if err := unmarshalPayload(msg.Payload, v); err != nil {
+		return fmt.Errorf("unmarshal %s: %w", msg.Code, err)
+	}
+
+	return nil
+}
+
+func marshalPayload(v any) (string, error) {
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Pointer {
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return "", fmt.Errorf("cannot marshal %s, expected struct", val.Kind())
+	}
+
+	w := payloadWriter{}
+	if err := writeStruct(&w, val); err != nil {
+		return "", err
+	}
+
+	return w.result()
+}
+
+func unmarshalPayload(payload string, v any) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Pointer || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal into %T, expected pointer to struct", v)
+	}
+
+	r := newPayloadReader(payload)
+	if err := readStruct(r, val.Elem()); err != nil {
+		return err
+	}
+
+	return r.done()
+}
+
+func writeStruct(w *payloadWriter, val reflect.Value) error {
+	typ := val.Type()
+	specs := make([]fieldSpec, typ.NumField())
+	tagged := make([]bool, typ.NumField())
+
+	// anything past the last field that has to be sent is left out
+	last := -1
+	for i := range typ.NumField() {
+		tag, ok := typ.Field(i).Tag.Lookup("pkr")
+		if !ok {
+			continue
+		}
+
+		spec, err := parseTag(tag)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", typ.Field(i).Name, err)
+		}
+
+		specs[i] = spec
+		tagged[i] = true
+		if !spec.optional || !val.Field(i).IsZero() {
+			last = i
+		}
+	}
+
+	for i := range last + 1 {
+		if !tagged[i] {
+			continue
+		}
+
+		spec := specs[i]
+		if err := writeField(w, spec, val.Field(i)); err != nil {
+			return fmt.Errorf("field %s: %w", typ.Field(i).Name, err)
+		}
+	}
+
+	return nil
+}
+
+func writeField(w *payloadWriter, spec fieldSpec, field reflect.Value) error {
+	switch spec.kind {
+	case "struct":
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("struct tag on %s", field.Kind())
+		}
+		return writeStruct(w, field)
+
+	case "list":
+		if field.Kind() != reflect.Slice {
+			return fmt.Errorf("list tag on %s", field.Kind())
+		}
+
+		if err := writeBasic(w, spec.count, reflect.ValueOf(field.Len())); err != nil {
+			return err
+		}
+
+		for i := range field.Len() {
+			var err error
+			if spec.elem == "" {
+				err = writeStruct(w, field.Index(i))
+			} else {
+				err = writeBasic(w, spec.elem, field.Index(i))
+			}
+
+			if err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		return nil
+
+	default:
+		return writeBasic(w, spec.kind, field)
+	}
+}
+
+func writeBasic(w *payloadWriter, kind string, field reflect.Value) error {
+	switch kind {
+	case "smallint", "bigint", "varint":
+		var num int
+		switch field.Kind() {
+		case reflect.Bool:
+			if field.Bool() {
+				num = 1
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			num = int(field.Int())
+		default:
+			return fmt.Errorf("%s tag on %s", kind, field.Kind())
+		}
+
+		switch kind {
+		case "smallint":
+			w.smallInt(num)
+		case "bigint":
+			w.bigInt(num)
+		case "varint":
+			w.varInt(num)
+		}
+
+	case "string", "raw":
+		if field.Kind() != reflect.String {
+			return fmt.Errorf("%s tag on %s", kind, field.Kind())
+		}
+
+		if kind == "string" {
+			w.str(field.String())
+		} else {
+			w.raw(field.String())
+		}
+
+	default:
+		return fmt.Errorf("unknown pkr type %q", kind)
+	}
+
+	return w.err
+}
+
+func readStruct(r *payloadReader, val reflect.Value) error {
+	typ := val.Type()
+
+	for i := range typ.NumField() {
+		tag, ok := typ.Field(i).Tag.Lookup("pkr")
+		if !ok {
+			continue
+		}
+
+		spec, err := parseTag(tag)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", typ.Field(i).Name, err)
+		}
+
+		if spec.optional && r.empty() {
+			continue
+		}
+
+		if err := readField(r, spec, val.Field(i)); err != nil {
+			return fmt.Errorf("field %s: %w", typ.Field(i).Name, err)
+		}
+	}
+
+	return nil
+}
+
+func readField(r *payloadReader, spec fieldSpec, field reflect.Value) error {
+	switch spec.kind {
+	case "struct":
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("struct tag on %s", field.Kind())
+		}
+		return readStruct(r, field)
+
+	case "list":
+		if field.Kind() != reflect.Slice {
+			return fmt.Errorf("list tag on %s", field.Kind())
+		}
+
+		countVal := reflect.New(reflect.TypeFor[int]()).Elem()
+		if err := readBasic(r, spec.count, countVal); err != nil {
+			return err
+		}
+
+		count := int(countVal.Int())
+		if count < 0 {
+			return fmt.Errorf("negative list count %d", count)
+		}
+
+		list := reflect.MakeSlice(field.Type(), count, count)
+		for i := range count {
+			var err error
+			if spec.elem == "" {
+				err = readStruct(r, list.Index(i))
+			} else {
+				err = readBasic(r, spec.elem, list.Index(i))
+			}
+
+			if err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+
+		field.Set(list)
+		return nil
+
+	default:
+		return readBasic(r, spec.kind, field)
+	}
+}
+
+func readBasic(r *payloadReader, kind string, field reflect.Value) error {
+	switch kind {
+	case "smallint", "bigint", "varint":
+		var num int
+		switch kind {
+		case "smallint":
+			num = r.smallInt()
+		case "bigint":
+			num = r.bigInt()
+		case "varint":
+			num = r.varInt()
+		}
+
+		switch field.Kind() {
+		case reflect.Bool:
+			field.SetBool(num == 1)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			field.SetInt(int64(num))
+		default:
+			return fmt.Errorf("%s tag on %s", kind, field.Kind())
+		}
+
+	case "string", "raw":
+		if field.Kind() != reflect.String {
+			return fmt.Errorf("%s tag on %s", kind, field.Kind())
+		}
+
+		if kind == "string" {
+			field.SetString(r.str())
+		} else {
+			field.SetString(r.rest())
+		}
+
+	default:
+		return fmt.Errorf("unknown pkr type %q", kind)
+	}
+
+	return r.err
+}
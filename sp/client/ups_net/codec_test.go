@@ -0,0 +1,191 @@
+package ups_net
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func testRoomState() *RoomStateMsg {
+	return &RoomStateMsg{
+		Pot:            150,
+		HighBet:        50,
+		CardsDealt:     true,
+		Card1:          12,
+		Card2:          51,
+		CommunityCards: []int{0, 13, 26},
+		Players: []PlayerSnapshot{
+			{Nick: "alice", Chips: 250, IsReady: true, IsTurn: true, Action: ActionBet, ActionAmount: 50, RoundBet: 50, TotalBet: 75},
+			{Nick: "bob", Chips: 0, IsFolded: true, Action: ActionFold, TotalBet: 25},
+		},
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	withSeats := testRoomState()
+	withSeats.Seats = []string{"", "alice", "", "bob"}
+	withSeats.Dealer = 3
+
+	// the dealer is zero and optional, so it isn't written, the seats still are
+	seatsOnly := testRoomState()
+	seatsOnly.Seats = []string{"alice", "bob"}
+
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"RMST", testRoomState()},
+		{"RMST with seats", withSeats},
+		{"RMST with seats, dealer 0", seatsOnly},
+		{"CONN", &ConnMsg{Nick: "alice"}},
+		{"CONN with capabilities", &ConnMsg{Nick: "alice", Version: ProtocolVersion, Capabilities: []string{CapExtendedFrames, CapChat, CapRaise}}},
+		{"PNOK", &NickOKMsg{Version: ProtocolVersion, Capabilities: []string{CapChat}}},
+		{"SDWN", &ShowdownMsg{Hands: []ShowdownHand{{Nick: "alice", Card1: 0, Card2: 51}, {Nick: "bob", Card1: 7, Card2: 20}}}},
+		{"ACFL", &ActionFailMsg{Reason: "Raise to at least 100"}},
+		{"PJIN", &PlayerJoinedMsg{Player: PlayerSnapshot{Nick: "carol", Chips: 1000}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			netMsg, err := EncodeMsg(tt.msg)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			decoded, err := DecodeMsg(netMsg)
+			if err != nil {
+				t.Fatalf("decode %q: %v", netMsg.Payload, err)
+			}
+
+			if !reflect.DeepEqual(decoded, tt.msg) {
+				t.Errorf("round trip changed the message\n got: %+v\nwant: %+v", decoded, tt.msg)
+			}
+		})
+	}
+}
+
+func TestCodecOptionalFields(t *testing.T) {
+	old, err := Marshal(testRoomState())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withSeats := testRoomState()
+	withSeats.Seats = []string{"alice", "bob"}
+	withSeats.Dealer = 1
+	current, err := Marshal(withSeats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// new fields only go after the old layout
+	if !strings.HasPrefix(current.Payload, old.Payload) || current.Payload == old.Payload {
+		t.Fatalf("seats should be appended to the old RMST\n old: %q\n new: %q", old.Payload, current.Payload)
+	}
+
+	// an old server doesn't send them, they stay zero
+	var state RoomStateMsg
+	if err := Unmarshal(old, &state); err != nil {
+		t.Fatalf("old RMST: %v", err)
+	}
+	if state.Seats != nil || state.Dealer != 0 {
+		t.Errorf("old RMST got seats %v dealer %d", state.Seats, state.Dealer)
+	}
+
+	// CONN without capabilities is just the nick, like older clients send it
+	conn, err := Marshal(&ConnMsg{Nick: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := WriteString("bob"); conn.Payload != want {
+		t.Errorf("CONN payload %q, want %q", conn.Payload, want)
+	}
+
+	// a legacy server answers PNOK without a payload
+	var nickOK NickOKMsg
+	if err := Unmarshal(NetMsg{Code: CodeNickOK}, &nickOK); err != nil {
+		t.Fatalf("empty PNOK: %v", err)
+	}
+	if nickOK.Version != 0 || nickOK.Capabilities != nil {
+		t.Errorf("empty PNOK decoded as %+v", nickOK)
+	}
+}
+
+func TestCodecRawField(t *testing.T) {
+	// raw takes everything left, even if it looks like other fields
+	reason := "0012Not a string"
+	msg, err := Marshal(&ActionFailMsg{Reason: reason})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Payload != reason {
+		t.Errorf("raw payload %q, want %q", msg.Payload, reason)
+	}
+
+	var fail ActionFailMsg
+	if err := Unmarshal(msg, &fail); err != nil || fail.Reason != reason {
+		t.Errorf("got %q, %v", fail.Reason, err)
+	}
+}
+
+func TestCodecErrors(t *testing.T) {
+	if _, err := Marshal(struct{}{}); err == nil {
+		t.Error("marshal without Code() should fail")
+	}
+
+	if err := Unmarshal(NetMsg{Code: CodeShowdown}, &GameWinMsg{}); err == nil {
+		t.Error("unmarshal into the wrong message should fail")
+	}
+
+	if _, err := Marshal(&CardsToPlayerMsg{Card1: 100}); err == nil {
+		t.Error("small int over 99 should fail")
+	}
+
+	var bad struct {
+		Count int `pkr:"list"`
+	}
+	if err := unmarshalPayload("01", &bad); err == nil {
+		t.Error("list without a count type should fail")
+	}
+}
+
+func TestCodecTruncated(t *testing.T) {
+	withSeats := testRoomState()
+	withSeats.Seats = []string{"alice", "", "bob"}
+	withSeats.Dealer = 2
+
+	old, _ := Marshal(testRoomState())
+	seatsOnly := *withSeats
+	seatsOnly.Dealer = 0
+	noDealer, _ := Marshal(&seatsOnly)
+
+	tests := []struct {
+		name string
+		msg  Message
+		// prefix lengths that are a complete message on their own
+		valid []int
+	}{
+		{"RMST", withSeats, []int{len(old.Payload), len(noDealer.Payload)}},
+		{"CONN", &ConnMsg{Nick: "alice", Version: 2, Capabilities: []string{CapChat}}, []int{len("0005alice"), len("0005alice02")}},
+		{"SDWN", &ShowdownMsg{Hands: []ShowdownHand{{Nick: "alice", Card1: 1, Card2: 2}, {Nick: "bob", Card1: 3, Card2: 4}}}, nil},
+		{"PACT", &PlayerActionMsg{Nick: "alice", Action: ActionBet, Amount: 1234}, nil},
+		{"PJIN", &PlayerJoinedMsg{Player: PlayerSnapshot{Nick: "carol", Chips: 1000}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			full, err := EncodeMsg(tt.msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for cut := range len(full.Payload) {
+				_, err := DecodeMsg(NetMsg{Code: full.Code, Payload: full.Payload[:cut]})
+				if valid := slices.Contains(tt.valid, cut); valid != (err == nil) {
+					t.Errorf("cut at %d of %q: valid %v, got err %v", cut, full.Payload, valid, err)
+				}
+			}
+		})
+	}
+}
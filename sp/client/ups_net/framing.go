@@ -0,0 +1,215 @@
+package ups_net
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrBadMagic       = errors.New("bad magic")
+	ErrBadType        = errors.New("bad message type")
+	ErrBadCode        = errors.New("bad message code")
+	ErrBadLength      = errors.New("bad payload length")
+	ErrMissingNewline = errors.New("message not terminated by an endline")
+)
+
+const maxPayloadLen = 9999
+
+// FrameReader reads whole PKR frames from any io.Reader.
+// Unlike Parser it does not have to be fed, it pulls bytes as it needs them.
+type FrameReader struct {
+	r *bufio.Reader
+}
+
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{r: bufio.NewReader(r)}
+}
+
+// ReadFrame returns io.EOF only if the stream ended cleanly between frames
+func (fr *FrameReader) ReadFrame() (NetMsg, error) {
+	// magic + type + code
+	var header [3 + 1 + MSG_CODE_SIZE]byte
+
+	if _, err := io.ReadFull(fr.r, header[:]); err != nil {
+		return NetMsg{}, err
+	}
+
+	if string(header[:3]) != magicStr {
+		return NetMsg{}, fmt.Errorf("%w: %q", ErrBadMagic, header[:3])
+	}
+
+	msgType := MsgType(header[3])
+	if msgType != PayloadMsg && msgType != NoPayloadMsg && msgType != ExtendedMsg {
+		return NetMsg{}, fmt.Errorf("%w: %q", ErrBadType, header[3])
+	}
+
+	msg := NetMsg{Code: string(header[4:])}
+
+	if msgType != NoPayloadMsg {
+		var size uint64
+		var err error
+		if msgType == ExtendedMsg {
+			size, err = fr.readExtendedSize()
+		} else {
+			size, err = fr.readSize()
+		}
+
+		if err != nil {
+			return NetMsg{}, err
+		}
+
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(fr.r, payload); err != nil {
+			return NetMsg{}, unexpectedEOF(err)
+		}
+
+		msg.Payload = string(payload)
+	}
+
+	endline, err := fr.r.ReadByte()
+	if err != nil {
+		return NetMsg{}, unexpectedEOF(err)
+	}
+
+	if endline != '\n' {
+		return NetMsg{}, fmt.Errorf("%w: got %q", ErrMissingNewline, endline)
+	}
+
+	return msg, nil
+}
+
+func (fr *FrameReader) readSize() (uint64, error) {
+	var sizeStr [PAYLOAD_LEN_SIZE]byte
+	if _, err := io.ReadFull(fr.r, sizeStr[:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+
+	size, ok := parseDigits(sizeStr[:])
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrBadLength, sizeStr[:])
+	}
+
+	return size, nil
+}
+
+// extended frames carry the length as a VarInt, so digit count first
+func (fr *FrameReader) readExtendedSize() (uint64, error) {
+	var countStr [2]byte
+	if _, err := io.ReadFull(fr.r, countStr[:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+
+	count, ok := parseDigits(countStr[:])
+	if !ok || count == 0 {
+		return 0, fmt.Errorf("%w: digit count %q", ErrBadLength, countStr[:])
+	}
+
+	sizeStr := make([]byte, count)
+	if _, err := io.ReadFull(fr.r, sizeStr); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+
+	var size uint64
+	for _, char := range sizeStr {
+		if char < '0' || char > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrBadLength, sizeStr)
+		}
+
+		size = size*10 + uint64(char-'0')
+		if size > MAX_EXT_PAYLOAD_LEN {
+			return 0, fmt.Errorf("%w: over %d bytes", ErrBadLength, MAX_EXT_PAYLOAD_LEN)
+		}
+	}
+
+	// an empty payload is always sent as 'N', same check as the Parser
+	if size == 0 {
+		return 0, fmt.Errorf("%w: empty extended frame", ErrBadLength)
+	}
+
+	return size, nil
+}
+
+// parseDigits reads a fixed width number. ReadBigInt would do, but it logs
+// every call and this runs for every frame.
+func parseDigits(digits []byte) (uint64, bool) {
+	var num uint64
+	for _, char := range digits {
+		if char < '0' || char > '9' {
+			return 0, false
+		}
+		num = num*10 + uint64(char-'0')
+	}
+	return num, true
+}
+
+// the stream ending inside a frame is never a clean EOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// FrameWriter writes PKR frames straight to an io.Writer, one flush per frame
+type FrameWriter struct {
+	w        *bufio.Writer
+	extended bool
+}
+
+func NewFrameWriter(w io.Writer) *FrameWriter {
+	return &FrameWriter{w: bufio.NewWriter(w)}
+}
+
+// SetExtended allows 'X' frames for payloads over 9999 bytes.
+// Only turn it on once the other side said it can read them.
+func (fw *FrameWriter) SetExtended(enabled bool) {
+	fw.extended = enabled
+}
+
+func (fw *FrameWriter) WriteFrame(msg NetMsg) error {
+	if uint64(len(msg.Code)) != MSG_CODE_SIZE {
+		return fmt.Errorf("%w: %q", ErrBadCode, msg.Code)
+	}
+
+	extended := len(msg.Payload) > maxPayloadLen
+	if extended && (!fw.extended || uint64(len(msg.Payload)) > MAX_EXT_PAYLOAD_LEN) {
+		return fmt.Errorf("%w: %d bytes", ErrBadLength, len(msg.Payload))
+	}
+
+	fw.w.WriteString(magicStr)
+
+	switch {
+	case extended:
+		fw.w.WriteByte(byte(ExtendedMsg))
+		fw.w.WriteString(msg.Code)
+
+		// only the few digits of the length, the payload is written as is
+		sizeStr, _ := WriteVarInt(len(msg.Payload))
+		fw.w.WriteString(sizeStr)
+		fw.w.WriteString(msg.Payload)
+
+	case len(msg.Payload) > 0:
+		fw.w.WriteByte(byte(PayloadMsg))
+		fw.w.WriteString(msg.Code)
+
+		// fixed width length without going through Sprintf
+		size := len(msg.Payload)
+		var sizeStr [PAYLOAD_LEN_SIZE]byte
+		for i := len(sizeStr) - 1; i >= 0; i-- {
+			sizeStr[i] = byte('0' + size%10)
+			size /= 10
+		}
+
+		fw.w.Write(sizeStr[:])
+		fw.w.WriteString(msg.Payload)
+
+	default:
+		fw.w.WriteByte(byte(NoPayloadMsg))
+		fw.w.WriteString(msg.Code)
+	}
+
+	fw.w.WriteByte('\n')
+	return fw.w.Flush()
+}
@@ -0,0 +1,236 @@
+package ups_net
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	msgs := []NetMsg{
+		{Code: CodeReady},
+		{Code: CodeBet, Payload: "0250"},
+		{Code: CodeChat, Payload: "has\nnewlines and PKRP inside"},
+		{Code: CodeRoomState, Payload: strings.Repeat("x", maxPayloadLen)},
+	}
+
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	for _, msg := range msgs {
+		if err := fw.WriteFrame(msg); err != nil {
+			t.Fatalf("write %s: %v", msg.Code, err)
+		}
+	}
+
+	// the same bytes the old string building produced
+	want := ""
+	for _, msg := range msgs {
+		want += msg.ToString()
+	}
+	if buf.String() != want {
+		t.Fatalf("frames differ from ToString")
+	}
+
+	fr := NewFrameReader(&buf)
+	for _, msg := range msgs {
+		got, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("read %s: %v", msg.Code, err)
+		}
+		if got != msg {
+			t.Errorf("got %s with %d bytes, want %s with %d bytes", got.Code, len(got.Payload), msg.Code, len(msg.Payload))
+		}
+	}
+
+	// nothing left is a clean EOF
+	if _, err := fr.ReadFrame(); err != io.EOF {
+		t.Errorf("after the last frame got %v, want io.EOF", err)
+	}
+}
+
+func TestFrameReaderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"bad magic", "PKXNRDY1\n", ErrBadMagic},
+		{"bad type", "PKRQRDY1\n", ErrBadType},
+		{"letter in the length", "PKRPBETT00a4abcd\n", ErrBadLength},
+		{"missing newline", "PKRNRDY1X", ErrMissingNewline},
+		{"payload longer than the length", "PKRPBETT0002abc\n", ErrMissingNewline},
+		{"cut in the header", "PKRPBE", io.ErrUnexpectedEOF},
+		{"cut in the length", "PKRPBETT00", io.ErrUnexpectedEOF},
+		{"cut in the payload", "PKRPBETT0004ab", io.ErrUnexpectedEOF},
+		{"cut before the newline", "PKRPBETT0004abcd", io.ErrUnexpectedEOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewFrameReader(strings.NewReader(tt.input)).ReadFrame()
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrameWriterErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  NetMsg
+		want error
+	}{
+		{"short code", NetMsg{Code: "RDY"}, ErrBadCode},
+		{"long code", NetMsg{Code: "READY"}, ErrBadCode},
+		{"payload over 9999", NetMsg{Code: CodeRoomState, Payload: strings.Repeat("x", maxPayloadLen+1)}, ErrBadLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewFrameWriter(&buf).WriteFrame(tt.msg); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("%d bytes written for a bad frame", buf.Len())
+			}
+		})
+	}
+}
+
+// extendedFrame builds a PKRX frame by hand so broken ones can be tested too
+func extendedFrame(code, digits, payload string) string {
+	return magicStr + string(ExtendedMsg) + code + digits + payload + "\n"
+}
+
+func TestExtendedFrames(t *testing.T) {
+	long := NetMsg{Code: CodeRoomState, Payload: strings.Repeat("x", maxPayloadLen+1)}
+
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	fw.SetExtended(true)
+
+	// short frames keep the old format even with XFRM on
+	short := NetMsg{Code: CodeBet, Payload: "0250"}
+	ready := NetMsg{Code: CodeReady}
+	for _, msg := range []NetMsg{long, short, ready} {
+		if err := fw.WriteFrame(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := long.ToExtendedString() + short.ToString() + ready.ToString()
+	if buf.String() != want {
+		t.Fatalf("written frames differ from ToExtendedString/ToString")
+	}
+	if header := extendedFrame(CodeRoomState, "0510000", ""); !strings.HasPrefix(want, header[:len(header)-1]) {
+		t.Errorf("extended header %q", want[:len(header)-1])
+	}
+
+	// an extended frame without payload falls back to the normal one
+	if ready.ToExtendedString() != ready.ToString() {
+		t.Errorf("empty extended frame %q", ready.ToExtendedString())
+	}
+
+	frames := buf.String()
+
+	fr := NewFrameReader(strings.NewReader(frames))
+	for _, msg := range []NetMsg{long, short, ready} {
+		got, err := fr.ReadFrame()
+		if err != nil || got != msg {
+			t.Fatalf("FrameReader got %s (%d bytes), %v", got.Code, len(got.Payload), err)
+		}
+	}
+
+	parser := Parser{}
+	parser.Init()
+	res := parser.ParseBytes([]byte(frames))
+	if res.Error || !res.parser_done || res.code != long.Code || res.payload != long.Payload {
+		t.Fatalf("Parser got %s (%d bytes), error %v", res.code, len(res.payload), res.Error)
+	}
+	if res.BytesParsed != uint64(len(long.ToExtendedString())) {
+		t.Errorf("Parser took %d bytes, the frame has %d", res.BytesParsed, len(long.ToExtendedString()))
+	}
+}
+
+func TestExtendedFrameErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+	}{
+		{"zero digit count", extendedFrame(CodeRoomState, "00", "abc")},
+		{"zero length", extendedFrame(CodeRoomState, "010", "")},
+		{"letter in the length", extendedFrame(CodeRoomState, "031a2", "x")},
+		{"letter in the digit count", extendedFrame(CodeRoomState, "0a1", "x")},
+		{"over 16 MiB", extendedFrame(CodeRoomState, "0816777217", "x")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFrameReader(strings.NewReader(tt.frame)).ReadFrame(); !errors.Is(err, ErrBadLength) {
+				t.Errorf("FrameReader got %v, want %v", err, ErrBadLength)
+			}
+
+			parser := Parser{}
+			parser.Init()
+			if res := parser.ParseBytes([]byte(tt.frame)); !res.Error {
+				t.Errorf("Parser accepted %q", tt.frame)
+			}
+		})
+	}
+
+	// exactly 16 MiB is allowed, the stream just ends before the payload does
+	limit := extendedFrame(CodeRoomState, "0816777216", "x")
+	if _, err := NewFrameReader(strings.NewReader(limit)).ReadFrame(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("16 MiB frame got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	parser := Parser{}
+	parser.Init()
+	if res := parser.ParseBytes([]byte(limit)); res.Error || res.parser_done {
+		t.Errorf("Parser on a 16 MiB frame: error %v, done %v", res.Error, res.parser_done)
+	}
+
+	// only the writer knows about the limit before anything is sent
+	fw := NewFrameWriter(io.Discard)
+	fw.SetExtended(true)
+	huge := NetMsg{Code: CodeRoomState, Payload: strings.Repeat("x", int(MAX_EXT_PAYLOAD_LEN)+1)}
+	if err := fw.WriteFrame(huge); !errors.Is(err, ErrBadLength) {
+		t.Errorf("writing over 16 MiB got %v, want %v", err, ErrBadLength)
+	}
+}
+
+func TestLongFields(t *testing.T) {
+	// a String field keeps its 4 digit length, XFRM or not
+	if _, ok := WriteString(strings.Repeat("x", maxPayloadLen+1)); ok {
+		t.Error("WriteString took more than 9999 bytes")
+	}
+
+	// a raw last field has no length of its own, so only the frame limits it
+	reason := strings.Repeat("y", 3*maxPayloadLen)
+	msg, err := EncodeMsg(&ActionFailMsg{Reason: reason})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf)
+	fw.SetExtended(true)
+	if err := fw.WriteFrame(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := NewFrameReader(&buf).ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeMsg(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.(*ActionFailMsg).Reason; got != reason {
+		t.Errorf("got %d bytes back, sent %d", len(got), len(reason))
+	}
+}
@@ -0,0 +1,740 @@
+package ups_net
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Message codes, kept in the same order as Babel.hpp on the server
+const (
+	// Connection handshake (Client <-> Server)
+	CodeConn      = "CONN" // Client: Conn with nick
+	CodeNickOK    = "PNOK" // Server: Player nick OK
+	CodeReconnect = "RCON" // Both: Ask reconnect / accept reconnect
+	CodeFail      = "FAIL" // Server: Generic failure
+	CodeFull      = "FULL" // Server: Server is full
+	CodeInfo      = "PINF" // Client: Send player info
+	CodeInfoOK    = "PIOK" // Server: Player info OK
+
+	// Room listing (Client <-> Server)
+	CodeRoomRequest = "RMRQ" // Client: Request room list
+	CodeRoom        = "ROOM" // Server: Room info
+	CodeRoomsDone   = "DONE" // Server: End of room list
+	CodeRoomOK      = "RMOK" // Client: Room received OK
+	CodeRoomFail    = "RMFL" // Client: Room received fail
+
+	// Room updates (Server -> Client)
+	CodeRoomUpdate = "RMUP" // Server: Room update
+	CodeUpdateOK   = "UPOK" // Client: Update OK
+	CodeUpdateFail = "UPFL" // Client: Update fail
+
+	// Join room (Client <-> Server)
+	CodeJoin     = "JOIN" // Client: Join request
+	CodeJoinOK   = "JNOK" // Server: Join OK
+	CodeJoinFail = "JNFL" // Server: Join failed
+
+	// Spectate room (Client <-> Server)
+	CodeSpectate     = "SPEC" // Client: Watch a room without a seat
+	CodeSpectateOK   = "SPOK" // Server: Spectate OK
+	CodeSpectateFail = "SPFL" // Server: Spectate failed
+
+	// Room state sync (Server <-> Client)
+	CodeRoomState    = "RMST" // Server: Room state
+	CodeStateOK      = "STOK" // Client: State OK
+	CodeStateFail    = "STFL" // Client: State fail
+	CodePlayerJoined = "PJIN" // Server: Player joined
+
+	// Chat (Client <-> Server), only with the CHAT capability
+	CodeChat        = "CHAT" // Client: Say something to the room or lobby
+	CodeChatMessage = "CHMS" // Server: Chat message broadcast
+	CodeChatFail    = "CHFL" // Server: Chat message rejected
+
+	// In-room actions (Client -> Room)
+	CodeReady = "RDY1" // Client: Player ready
+	CodeLeave = "GMLV" // Client: Leave room
+	CodeCheck = "CHCK" // Client: Check
+	CodeFold  = "FOLD" // Client: Fold
+	CodeCall  = "CALL" // Client: Call
+	CodeBet   = "BETT" // Client: Bet amount
+
+	// In-room responses (Room -> Client)
+	CodePlayerReady   = "PRDY" // Server: Player X ready broadcast
+	CodeGameStart     = "GMST" // Server: Game started (room locked)
+	CodeGameRound     = "GMRD" // Server: Game round
+	CodeCardsToPlayer = "CDTP" // Server: Card to player (2 cards)
+	CodePlayerTurn    = "PTRN" // Server: Player [Nick] turn
+	CodeActionOK      = "ACOK" // Server: Action OK
+	CodeActionFail    = "ACFL" // Server: Action failed
+	CodeNotYourTurn   = "NYET" // Server: Not your turn
+	CodePlayerAction  = "PACT" // Server: Player action
+	CodeTimeout       = "TOUT" // Server: Player action timedout
+	CodeCommunityCard = "CRVR" // Server: Card added to river
+
+	// In-room responses (Client -> Room)
+	CodeCardsOK   = "CDOK"
+	CodeCardsFail = "CDFL"
+
+	// Showdown (Server -> Client)
+	CodeShowdown     = "SDWN" // Server: Showdown with all cards
+	CodeShowdownOK   = "SDOK" // Client: Showdown OK
+	CodeShowdownFail = "SDFL" // Client: Showdown fail
+
+	// Win (Server -> Client)
+	CodeGameWin  = "GWIN" // Server: Win with winner's nick
+	CodeGameLost = "GLOS" // Server: Everyone lost (all folded)
+	CodeWinOK    = "GWOK" // Client: Win OK
+	CodeWinFail  = "GWFL" // Client: Win fail
+
+	// Game end (Server -> Client)
+	CodeGameDone = "GMDN" // Server: Game concluded
+	CodeDoneOK   = "DNOK" // Client: Done OK
+	CodeDoneFail = "DNFL" // Client: Done fail
+
+	// Disconnect (Both directions)
+	CodeDisconnect = "DCON" // Forceful disconnect
+
+	// Keepalive, handled inside NetHandler
+	CodeAliveRequest = "ALV?"
+	CodeAliveReply   = "ALV!"
+	CodePing         = "PING"
+)
+
+// Action ids as sent in PACT and in the player layout of RMST/PJIN
+const (
+	ActionNone = iota
+	ActionCheck
+	ActionCall
+	ActionFold
+	ActionBet
+	ActionLeft
+)
+
+var ErrUnknownCode = errors.New("unknown message code")
+
+// Message is a typed PKR message, the payload layout is owned by Encode/Decode
+type Message interface {
+	Code() string
+	Encode() (string, error)
+	Decode(payload string) error
+}
+
+var registry = map[string]func() Message{
+	CodeConn:          func() Message { return &ConnMsg{} },
+	CodeNickOK:        func() Message { return &NickOKMsg{} },
+	CodeReconnect:     func() Message { return &ReconnectMsg{} },
+	CodeFail:          func() Message { return &FailMsg{} },
+	CodeFull:          func() Message { return &FullMsg{} },
+	CodeInfo:          func() Message { return &InfoMsg{} },
+	CodeInfoOK:        func() Message { return &InfoOKMsg{} },
+	CodeRoomRequest:   func() Message { return &RoomRequestMsg{} },
+	CodeRoom:          func() Message { return &RoomMsg{} },
+	CodeRoomsDone:     func() Message { return &RoomsDoneMsg{} },
+	CodeRoomOK:        func() Message { return &RoomOKMsg{} },
+	CodeRoomFail:      func() Message { return &RoomFailMsg{} },
+	CodeRoomUpdate:    func() Message { return &RoomUpdateMsg{} },
+	CodeUpdateOK:      func() Message { return &UpdateOKMsg{} },
+	CodeUpdateFail:    func() Message { return &UpdateFailMsg{} },
+	CodeJoin:          func() Message { return &JoinMsg{} },
+	CodeJoinOK:        func() Message { return &JoinOKMsg{} },
+	CodeJoinFail:      func() Message { return &JoinFailMsg{} },
+	CodeSpectate:      func() Message { return &SpectateMsg{} },
+	CodeSpectateOK:    func() Message { return &SpectateOKMsg{} },
+	CodeSpectateFail:  func() Message { return &SpectateFailMsg{} },
+	CodeRoomState:     func() Message { return &RoomStateMsg{} },
+	CodeStateOK:       func() Message { return &StateOKMsg{} },
+	CodeStateFail:     func() Message { return &StateFailMsg{} },
+	CodePlayerJoined:  func() Message { return &PlayerJoinedMsg{} },
+	CodeChat:          func() Message { return &ChatMsg{} },
+	CodeChatMessage:   func() Message { return &ChatMessageMsg{} },
+	CodeChatFail:      func() Message { return &ChatFailMsg{} },
+	CodeReady:         func() Message { return &ReadyMsg{} },
+	CodeLeave:         func() Message { return &LeaveMsg{} },
+	CodeCheck:         func() Message { return &CheckMsg{} },
+	CodeFold:          func() Message { return &FoldMsg{} },
+	CodeCall:          func() Message { return &CallMsg{} },
+	CodeBet:           func() Message { return &BetMsg{} },
+	CodePlayerReady:   func() Message { return &PlayerReadyMsg{} },
+	CodeGameStart:     func() Message { return &GameStartMsg{} },
+	CodeGameRound:     func() Message { return &GameRoundMsg{} },
+	CodeCardsToPlayer: func() Message { return &CardsToPlayerMsg{} },
+	CodePlayerTurn:    func() Message { return &PlayerTurnMsg{} },
+	CodeActionOK:      func() Message { return &ActionOKMsg{} },
+	CodeActionFail:    func() Message { return &ActionFailMsg{} },
+	CodeNotYourTurn:   func() Message { return &NotYourTurnMsg{} },
+	CodePlayerAction:  func() Message { return &PlayerActionMsg{} },
+	CodeTimeout:       func() Message { return &TimeoutMsg{} },
+	CodeCommunityCard: func() Message { return &CommunityCardMsg{} },
+	CodeCardsOK:       func() Message { return &CardsOKMsg{} },
+	CodeCardsFail:     func() Message { return &CardsFailMsg{} },
+	CodeShowdown:      func() Message { return &ShowdownMsg{} },
+	CodeShowdownOK:    func() Message { return &ShowdownOKMsg{} },
+	CodeShowdownFail:  func() Message { return &ShowdownFailMsg{} },
+	CodeGameWin:       func() Message { return &GameWinMsg{} },
+	CodeGameLost:      func() Message { return &GameLostMsg{} },
+	CodeWinOK:         func() Message { return &WinOKMsg{} },
+	CodeWinFail:       func() Message { return &WinFailMsg{} },
+	CodeGameDone:      func() Message { return &GameDoneMsg{} },
+	CodeDoneOK:        func() Message { return &DoneOKMsg{} },
+	CodeDoneFail:      func() Message { return &DoneFailMsg{} },
+	CodeDisconnect:    func() Message { return &DisconnectMsg{} },
+	CodeAliveRequest:  func() Message { return &AliveRequestMsg{} },
+	CodeAliveReply:    func() Message { return &AliveReplyMsg{} },
+	CodePing:          func() Message { return &PingMsg{} },
+}
+
+// NewMessage returns an empty message for the given code
+func NewMessage(code string) (Message, bool) {
+	factory, ok := registry[code]
+	if !ok {
+		return nil, false
+	}
+
+	return factory(), true
+}
+
+// DecodeMsg turns a raw NetMsg into its typed counterpart
+func DecodeMsg(msg NetMsg) (Message, error) {
+	typed, ok := NewMessage(msg.Code)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCode, msg.Code)
+	}
+
+	if err := typed.Decode(msg.Payload); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", msg.Code, err)
+	}
+
+	return typed, nil
+}
+
+// EncodeMsg turns a typed message into a NetMsg ready for sending
+func EncodeMsg(m Message) (NetMsg, error) {
+	payload, err := m.Encode()
+	if err != nil {
+		return NetMsg{}, fmt.Errorf("encoding %s: %w", m.Code(), err)
+	}
+
+	return NetMsg{Code: m.Code(), Payload: payload}, nil
+}
+
+// payloadReader reads the basic data types in order, the first failure sticks
+type payloadReader struct {
+	buf []byte
+	off int
+	err error
+}
+
+func newPayloadReader(payload string) *payloadReader {
+	return &payloadReader{buf: []byte(payload)}
+}
+
+func (r *payloadReader) fail(what string) {
+	if r.err == nil {
+		r.err = fmt.Errorf("failed when reading %s at offset %d", what, r.off)
+	}
+}
+
+func (r *payloadReader) smallInt() int {
+	if r.err != nil {
+		return 0
+	}
+
+	num, ok := ReadSmallInt(r.buf[r.off:])
+	if !ok {
+		r.fail("small int")
+		return 0
+	}
+
+	r.off += 2
+	return num
+}
+
+func (r *payloadReader) bigInt() int {
+	if r.err != nil {
+		return 0
+	}
+
+	num, ok := ReadBigInt(r.buf[r.off:])
+	if !ok {
+		r.fail("big int")
+		return 0
+	}
+
+	r.off += 4
+	return num
+}
+
+func (r *payloadReader) varInt() int {
+	if r.err != nil {
+		return 0
+	}
+
+	num, ok := ReadVarInt(r.buf[r.off:])
+	if !ok {
+		r.fail("var int")
+		return 0
+	}
+
+	// the length prefix says how many digits were consumed
+	length, _ := ReadSmallInt(r.buf[r.off:])
+	r.off += 2 + length
+	return int(num)
+}
+
+func (r *payloadReader) str() string {
+	if r.err != nil {
+		return ""
+	}
+
+	str, ok := ReadString(r.buf[r.off:])
+	if !ok {
+		r.fail("string")
+		return ""
+	}
+
+	r.off += 4 + len(str)
+	return str
+}
+
+func (r *payloadReader) rest() string {
+	if r.err != nil {
+		return ""
+	}
+
+	rest := string(r.buf[r.off:])
+	r.off = len(r.buf)
+	return rest
+}
+
+// empty is true once the whole payload has been consumed
+func (r *payloadReader) empty() bool {
+	return r.err == nil && r.off >= len(r.buf)
+}
+
+// done reports the first read error. Trailing bytes are allowed on purpose,
+// newer peers may append fields and the server pads SDWN with empty seats
+func (r *payloadReader) done() error {
+	return r.err
+}
+
+// payloadWriter mirrors payloadReader for the Write* functions
+type payloadWriter struct {
+	buf []byte
+	err error
+}
+
+func (w *payloadWriter) put(what string, str string, ok bool) {
+	if w.err != nil {
+		return
+	}
+
+	if !ok {
+		w.err = fmt.Errorf("value out of range for %s", what)
+		return
+	}
+
+	w.buf = append(w.buf, str...)
+}
+
+func (w *payloadWriter) smallInt(num int) {
+	str, ok := WriteSmallInt(num)
+	w.put("small int", str, ok)
+}
+
+func (w *payloadWriter) bigInt(num int) {
+	str, ok := WriteBigInt(num)
+	w.put("big int", str, ok)
+}
+
+func (w *payloadWriter) varInt(num int) {
+	str, ok := WriteVarInt(num)
+	w.put("var int", str, ok)
+}
+
+func (w *payloadWriter) str(s string) {
+	str, ok := WriteString(s)
+	w.put("string", str, ok)
+}
+
+func (w *payloadWriter) raw(s string) {
+	w.put("raw", s, true)
+}
+
+func (w *payloadWriter) result() (string, error) {
+	return string(w.buf), w.err
+}
+
+// noPayload is embedded by every message that is just a code
+type noPayload struct{}
+
+func (noPayload) Encode() (string, error) { return "", nil }
+
+func (noPayload) Decode(payload string) error {
+	if payload != "" {
+		return fmt.Errorf("unexpected payload of %d bytes", len(payload))
+	}
+	return nil
+}
+
+// PKRPCONN[Nick](Version)(CapCount)(Capability)...
+// Older servers stop reading after the nick, so the rest is free to add
+type ConnMsg struct {
+	Nick         string   `pkr:"string"`
+	Version      int      `pkr:"smallint,optional"`
+	Capabilities []string `pkr:"list,count=smallint,elem=string,optional"`
+}
+
+// PKRNPNOK or PKRPPNOK(Version)(CapCount)(Capability)...
+// An older server answers without a payload, meaning LegacyVersion and no capabilities
+type NickOKMsg struct {
+	Version      int      `pkr:"smallint,optional"`
+	Capabilities []string `pkr:"list,count=smallint,elem=string,optional"`
+}
+
+// PKRNRCON or PKRPRCON(Version)(CapCount)(Capability)...
+// The server asks this instead of PNOK, so it carries the same answer.
+// The client replies with an empty RCON.
+type ReconnectMsg struct {
+	Version      int      `pkr:"smallint,optional"`
+	Capabilities []string `pkr:"list,count=smallint,elem=string,optional"`
+}
+
+type FailMsg struct{ noPayload }
+type FullMsg struct{ noPayload }
+
+// PKRPPINF[Chips]
+type InfoMsg struct {
+	Chips int `pkr:"varint"`
+}
+
+type InfoOKMsg struct{ noPayload }
+type RoomRequestMsg struct{ noPayload }
+
+// PKRPROOM[ID][Name][CurrentPlayers][MaxPlayers]
+type RoomMsg struct {
+	ID             int    `pkr:"bigint"`
+	Name           string `pkr:"string"`
+	CurrentPlayers int    `pkr:"smallint"`
+	MaxPlayers     int    `pkr:"smallint"`
+}
+
+type RoomsDoneMsg struct{ noPayload }
+type RoomOKMsg struct{ noPayload }
+type RoomFailMsg struct{ noPayload }
+
+// PKRPRMUP[RoomID][MemberID][NewValue]
+// The type of NewValue depends on the member, so it is kept raw
+type RoomUpdateMsg struct {
+	RoomID   int    `pkr:"bigint"`
+	MemberID int    `pkr:"smallint"`
+	Value    string `pkr:"raw"`
+}
+
+// RMUP members, Value holds the payload of the matching type
+const (
+	UpdateTable  = 1 // RoomStateMsg, the whole table as the player may see it
+	UpdatePlayer = 2 // PlayerSnapshot
+	UpdatePot    = 3 // PotUpdate
+
+	// lobby side, sent to players looking at the room list
+	UpdateRoomName    = 4 // RoomNameUpdate
+	UpdateRoomPlayers = 5 // RoomCountUpdate, seated players
+	UpdateRoomMax     = 6 // RoomCountUpdate, seats in the room
+)
+
+// PotUpdate is the value of an UpdatePot RMUP
+type PotUpdate struct {
+	Pot     int `pkr:"varint"`
+	HighBet int `pkr:"varint"`
+}
+
+// RoomNameUpdate is the value of an UpdateRoomName RMUP
+type RoomNameUpdate struct {
+	Name string `pkr:"string"`
+}
+
+// RoomCountUpdate is the value of UpdateRoomPlayers and UpdateRoomMax
+type RoomCountUpdate struct {
+	Count int `pkr:"smallint"`
+}
+
+// NewRoomUpdate encodes v as the value for member
+func NewRoomUpdate(roomID int, member int, v any) (*RoomUpdateMsg, error) {
+	value, err := marshalPayload(v)
+	if err != nil {
+		return nil, fmt.Errorf("room update %d: %w", member, err)
+	}
+	return &RoomUpdateMsg{RoomID: roomID, MemberID: member, Value: value}, nil
+}
+
+// DecodeValue reads Value into v, which has to match MemberID
+func (m *RoomUpdateMsg) DecodeValue(v any) error {
+	if err := unmarshalPayload(m.Value, v); err != nil {
+		return fmt.Errorf("room update %d: %w", m.MemberID, err)
+	}
+	return nil
+}
+
+type UpdateOKMsg struct{ noPayload }
+type UpdateFailMsg struct{ noPayload }
+
+// PKRPJOIN[RoomID]
+type JoinMsg struct {
+	RoomID int `pkr:"bigint"`
+}
+
+type JoinOKMsg struct{ noPayload }
+type JoinFailMsg struct{ noPayload }
+
+// PKRPSPEC[RoomID]
+type SpectateMsg struct {
+	RoomID int `pkr:"bigint"`
+}
+
+type SpectateOKMsg struct{ noPayload }
+type SpectateFailMsg struct{ noPayload }
+
+// PlayerSnapshot is the player layout shared by RMST and PJIN
+type PlayerSnapshot struct {
+	Nick         string `pkr:"string"`
+	Chips        int    `pkr:"varint"`
+	IsFolded     bool   `pkr:"smallint"`
+	IsReady      bool   `pkr:"smallint"`
+	IsTurn       bool   `pkr:"smallint"`
+	Action       int    `pkr:"smallint"`
+	ActionAmount int    `pkr:"varint"`
+	RoundBet     int    `pkr:"varint"`
+	TotalBet     int    `pkr:"varint"`
+}
+
+// PKRPRMST[Pot][HighBet][CardsDealt][Card1][Card2][CommCount](Card)...[PlayerCount](Player)...(SeatCount(Nick)...)(Dealer)
+type RoomStateMsg struct {
+	Pot            int              `pkr:"varint"`
+	HighBet        int              `pkr:"varint"`
+	CardsDealt     bool             `pkr:"smallint"`
+	Card1          int              `pkr:"smallint"`
+	Card2          int              `pkr:"smallint"`
+	CommunityCards []int            `pkr:"list,count=smallint,elem=smallint"`
+	Players        []PlayerSnapshot `pkr:"list,count=smallint"`
+	Seats          []string         `pkr:"list,count=smallint,elem=string,optional"` // nick by seat index, empty for a free seat
+	Dealer         int              `pkr:"smallint,optional"`                        // seat index of the dealer button
+}
+
+type StateOKMsg struct{ noPayload }
+type StateFailMsg struct{ noPayload }
+
+// PKRPPJIN[Player]
+type PlayerJoinedMsg struct {
+	Player PlayerSnapshot `pkr:"struct"`
+}
+
+// PKRPCHAT[Text]
+type ChatMsg struct {
+	Text string `pkr:"string"`
+}
+
+// PKRPCHMS[Nick][Text]
+type ChatMessageMsg struct {
+	Nick string `pkr:"string"`
+	Text string `pkr:"string"`
+}
+
+type ChatFailMsg struct{ noPayload }
+
+type ReadyMsg struct{ noPayload }
+type LeaveMsg struct{ noPayload }
+type CheckMsg struct{ noPayload }
+type FoldMsg struct{ noPayload }
+type CallMsg struct{ noPayload }
+
+// PKRPBETT[Amount]
+type BetMsg struct {
+	Amount int `pkr:"varint"`
+}
+
+// PKRPPRDY[Nick]
+type PlayerReadyMsg struct {
+	Nick string `pkr:"string"`
+}
+
+type GameStartMsg struct{ noPayload }
+type GameRoundMsg struct{ noPayload }
+
+// PKRPCDTP[Card1][Card2]
+type CardsToPlayerMsg struct {
+	Card1 int `pkr:"smallint"`
+	Card2 int `pkr:"smallint"`
+}
+
+// PKRPPTRN[Nick]
+type PlayerTurnMsg struct {
+	Nick string `pkr:"string"`
+}
+
+type ActionOKMsg struct{ noPayload }
+
+// PKRPACFL[Reason], the server sends the reason as plain text
+type ActionFailMsg struct {
+	Reason string `pkr:"raw"`
+}
+
+type NotYourTurnMsg struct{ noPayload }
+
+// PKRPPACT[Nick][Action][Amount]
+type PlayerActionMsg struct {
+	Nick   string `pkr:"string"`
+	Action int    `pkr:"smallint"`
+	Amount int    `pkr:"varint"`
+}
+
+// PKRPTOUT[Nick]
+type TimeoutMsg struct {
+	Nick string `pkr:"string"`
+}
+
+// PKRPCRVR[Card]
+type CommunityCardMsg struct {
+	Card int `pkr:"smallint"`
+}
+
+type CardsOKMsg struct{ noPayload }
+type CardsFailMsg struct{ noPayload }
+
+type ShowdownHand struct {
+	Nick  string `pkr:"string"`
+	Card1 int    `pkr:"smallint"`
+	Card2 int    `pkr:"smallint"`
+}
+
+// PKRPSDWN[PairCount]([Nick][Card1][Card2])...
+type ShowdownMsg struct {
+	Hands []ShowdownHand `pkr:"list,count=smallint"`
+}
+
+type ShowdownOKMsg struct{ noPayload }
+type ShowdownFailMsg struct{ noPayload }
+
+// PKRPGWIN[Nick][Amount]
+type GameWinMsg struct {
+	Nick   string `pkr:"string"`
+	Amount int    `pkr:"varint"`
+}
+
+type GameLostMsg struct{ noPayload }
+type WinOKMsg struct{ noPayload }
+type WinFailMsg struct{ noPayload }
+type GameDoneMsg struct{ noPayload }
+type DoneOKMsg struct{ noPayload }
+type DoneFailMsg struct{ noPayload }
+type DisconnectMsg struct{ noPayload }
+type AliveRequestMsg struct{ noPayload }
+type AliveReplyMsg struct{ noPayload }
+type PingMsg struct{ noPayload }
+
+func (m *ConnMsg) Code() string          { return CodeConn }
+func (m *NickOKMsg) Code() string        { return CodeNickOK }
+func (m *ReconnectMsg) Code() string     { return CodeReconnect }
+func (m *FailMsg) Code() string          { return CodeFail }
+func (m *FullMsg) Code() string          { return CodeFull }
+func (m *InfoMsg) Code() string          { return CodeInfo }
+func (m *InfoOKMsg) Code() string        { return CodeInfoOK }
+func (m *RoomRequestMsg) Code() string   { return CodeRoomRequest }
+func (m *RoomMsg) Code() string          { return CodeRoom }
+func (m *RoomsDoneMsg) Code() string     { return CodeRoomsDone }
+func (m *RoomOKMsg) Code() string        { return CodeRoomOK }
+func (m *RoomFailMsg) Code() string      { return CodeRoomFail }
+func (m *RoomUpdateMsg) Code() string    { return CodeRoomUpdate }
+func (m *UpdateOKMsg) Code() string      { return CodeUpdateOK }
+func (m *UpdateFailMsg) Code() string    { return CodeUpdateFail }
+func (m *JoinMsg) Code() string          { return CodeJoin }
+func (m *JoinOKMsg) Code() string        { return CodeJoinOK }
+func (m *JoinFailMsg) Code() string      { return CodeJoinFail }
+func (m *SpectateMsg) Code() string      { return CodeSpectate }
+func (m *SpectateOKMsg) Code() string    { return CodeSpectateOK }
+func (m *SpectateFailMsg) Code() string  { return CodeSpectateFail }
+func (m *RoomStateMsg) Code() string     { return CodeRoomState }
+func (m *StateOKMsg) Code() string       { return CodeStateOK }
+func (m *StateFailMsg) Code() string     { return CodeStateFail }
+func (m *PlayerJoinedMsg) Code() string  { return CodePlayerJoined }
+func (m *ChatMsg) Code() string          { return CodeChat }
+func (m *ChatMessageMsg) Code() string   { return CodeChatMessage }
+func (m *ChatFailMsg) Code() string      { return CodeChatFail }
+func (m *ReadyMsg) Code() string         { return CodeReady }
+func (m *LeaveMsg) Code() string         { return CodeLeave }
+func (m *CheckMsg) Code() string         { return CodeCheck }
+func (m *FoldMsg) Code() string          { return CodeFold }
+func (m *CallMsg) Code() string          { return CodeCall }
+func (m *BetMsg) Code() string           { return CodeBet }
+func (m *PlayerReadyMsg) Code() string   { return CodePlayerReady }
+func (m *GameStartMsg) Code() string     { return CodeGameStart }
+func (m *GameRoundMsg) Code() string     { return CodeGameRound }
+func (m *CardsToPlayerMsg) Code() string { return CodeCardsToPlayer }
+func (m *PlayerTurnMsg) Code() string    { return CodePlayerTurn }
+func (m *ActionOKMsg) Code() string      { return CodeActionOK }
+func (m *ActionFailMsg) Code() string    { return CodeActionFail }
+func (m *NotYourTurnMsg) Code() string   { return CodeNotYourTurn }
+func (m *PlayerActionMsg) Code() string  { return CodePlayerAction }
+func (m *TimeoutMsg) Code() string       { return CodeTimeout }
+func (m *CommunityCardMsg) Code() string { return CodeCommunityCard }
+func (m *CardsOKMsg) Code() string       { return CodeCardsOK }
+func (m *CardsFailMsg) Code() string     { return CodeCardsFail }
+func (m *ShowdownMsg) Code() string      { return CodeShowdown }
+func (m *ShowdownOKMsg) Code() string    { return CodeShowdownOK }
+func (m *ShowdownFailMsg) Code() string  { return CodeShowdownFail }
+func (m *GameWinMsg) Code() string       { return CodeGameWin }
+func (m *GameLostMsg) Code() string      { return CodeGameLost }
+func (m *WinOKMsg) Code() string         { return CodeWinOK }
+func (m *WinFailMsg) Code() string       { return CodeWinFail }
+func (m *GameDoneMsg) Code() string      { return CodeGameDone }
+func (m *DoneOKMsg) Code() string        { return CodeDoneOK }
+func (m *DoneFailMsg) Code() string      { return CodeDoneFail }
+func (m *DisconnectMsg) Code() string    { return CodeDisconnect }
+func (m *AliveRequestMsg) Code() string  { return CodeAliveRequest }
+func (m *AliveReplyMsg) Code() string    { return CodeAliveReply }
+func (m *PingMsg) Code() string          { return CodePing }
+
+// Messages with a payload get their layout from the pkr tags
+func (m *ConnMsg) Encode() (string, error)          { return marshalPayload(m) }
+func (m *NickOKMsg) Encode() (string, error)        { return marshalPayload(m) }
+func (m *ReconnectMsg) Encode() (string, error)     { return marshalPayload(m) }
+func (m *InfoMsg) Encode() (string, error)          { return marshalPayload(m) }
+func (m *RoomMsg) Encode() (string, error)          { return marshalPayload(m) }
+func (m *RoomUpdateMsg) Encode() (string, error)    { return marshalPayload(m) }
+func (m *JoinMsg) Encode() (string, error)          { return marshalPayload(m) }
+func (m *SpectateMsg) Encode() (string, error)      { return marshalPayload(m) }
+func (m *RoomStateMsg) Encode() (string, error)     { return marshalPayload(m) }
+func (m *PlayerJoinedMsg) Encode() (string, error)  { return marshalPayload(m) }
+func (m *ChatMsg) Encode() (string, error)          { return marshalPayload(m) }
+func (m *ChatMessageMsg) Encode() (string, error)   { return marshalPayload(m) }
+func (m *BetMsg) Encode() (string, error)           { return marshalPayload(m) }
+func (m *PlayerReadyMsg) Encode() (string, error)   { return marshalPayload(m) }
+func (m *CardsToPlayerMsg) Encode() (string, error) { return marshalPayload(m) }
+func (m *PlayerTurnMsg) Encode() (string, error)    { return marshalPayload(m) }
+func (m *ActionFailMsg) Encode() (string, error)    { return marshalPayload(m) }
+func (m *PlayerActionMsg) Encode() (string, error)  { return marshalPayload(m) }
+func (m *TimeoutMsg) Encode() (string, error)       { return marshalPayload(m) }
+func (m *CommunityCardMsg) Encode() (string, error) { return marshalPayload(m) }
+func (m *ShowdownMsg) Encode() (string, error)      { return marshalPayload(m) }
+func (m *GameWinMsg) Encode() (string, error)       { return marshalPayload(m) }
+
+func (m *ConnMsg) Decode(payload string) error          { return unmarshalPayload(payload, m) }
+func (m *NickOKMsg) Decode(payload string) error        { return unmarshalPayload(payload, m) }
+func (m *ReconnectMsg) Decode(payload string) error     { return unmarshalPayload(payload, m) }
+func (m *InfoMsg) Decode(payload string) error          { return unmarshalPayload(payload, m) }
+func (m *RoomMsg) Decode(payload string) error          { return unmarshalPayload(payload, m) }
+func (m *RoomUpdateMsg) Decode(payload string) error    { return unmarshalPayload(payload, m) }
+func (m *JoinMsg) Decode(payload string) error          { return unmarshalPayload(payload, m) }
+func (m *SpectateMsg) Decode(payload string) error      { return unmarshalPayload(payload, m) }
+func (m *RoomStateMsg) Decode(payload string) error     { return unmarshalPayload(payload, m) }
+func (m *PlayerJoinedMsg) Decode(payload string) error  { return unmarshalPayload(payload, m) }
+func (m *ChatMsg) Decode(payload string) error          { return unmarshalPayload(payload, m) }
+func (m *ChatMessageMsg) Decode(payload string) error   { return unmarshalPayload(payload, m) }
+func (m *BetMsg) Decode(payload string) error           { return unmarshalPayload(payload, m) }
+func (m *PlayerReadyMsg) Decode(payload string) error   { return unmarshalPayload(payload, m) }
+func (m *CardsToPlayerMsg) Decode(payload string) error { return unmarshalPayload(payload, m) }
+func (m *PlayerTurnMsg) Decode(payload string) error    { return unmarshalPayload(payload, m) }
+func (m *ActionFailMsg) Decode(payload string) error    { return unmarshalPayload(payload, m) }
+func (m *PlayerActionMsg) Decode(payload string) error  { return unmarshalPayload(payload, m) }
+func (m *TimeoutMsg) Decode(payload string) error       { return unmarshalPayload(payload, m) }
+func (m *CommunityCardMsg) Decode(payload string) error { return unmarshalPayload(payload, m) }
+func (m *ShowdownMsg) Decode(payload string) error      { return unmarshalPayload(payload, m) }
+func (m *GameWinMsg) Decode(payload string) error       { return unmarshalPayload(payload, m) }
@@ -3,6 +3,7 @@ package ups_net
 import (
 	"fmt"
 	"net"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -12,10 +13,24 @@ const (
 	chanBufSize  = 100
 	arrBufSize   = 256
 	magicStr     = "PKR"
-	reconnectMax = 30
-	reconnectInt = 1 * time.Second
+	requestCheck = 100 * time.Millisecond
 )
 
+// NetConfig is what the user can tune about the connection
+type NetConfig struct {
+	ReconnectMax      int           // attempts before giving up
+	ReconnectInterval time.Duration // wait between attempts
+	AliveInterval     time.Duration // ALV? period, two missed answers drop the connection
+}
+
+func DefaultNetConfig() NetConfig {
+	return NetConfig{
+		ReconnectMax:      30,
+		ReconnectInterval: 1 * time.Second,
+		AliveInterval:     10 * time.Second,
+	}
+}
+
 // Network events sent to game thread
 type NetEvent any
 type NetConnecting struct{}
@@ -30,6 +45,13 @@ type NetMessage struct {
 	Msg NetMsg
 }
 
+// NetRequestTimeout is sent when a Request didn't get any of the expected
+// answers in time. The connection is reset right after it.
+type NetRequestTimeout struct {
+	Code     string
+	Expected []string
+}
+
 // Commands from game thread
 type NetCommand any
 type NetConnect struct {
@@ -45,6 +67,9 @@ type NetHandler struct {
 	userDisconnect atomic.Bool
 	state          atomic.Value
 	shutdown       atomic.Bool
+	serverInfo     atomic.Pointer[ServerInfo] // answer to CONN, reset on every connection
+	capture        *Capture                   // nil unless SetCapture was called
+	cfg            NetConfig
 
 	eventChan   chan NetEvent   // Network -> Game (events)
 	commandChan chan NetCommand // Game -> Network (commands)
@@ -62,6 +87,19 @@ type NetHandler struct {
 		active   bool
 		timer    *time.Timer
 	}
+
+	requestTimer *time.Ticker
+	pending      struct {
+		sync.Mutex
+		requests []pendingRequest
+	}
+}
+
+// a sent message still waiting for its answer
+type pendingRequest struct {
+	code     string
+	expected []string
+	deadline time.Time
 }
 
 type ConnectionState int
@@ -79,11 +117,24 @@ func (nh *NetHandler) Init() {
 	nh.msgOutChan = make(chan NetMsg, chanBufSize)
 	nh.state.Store(StateDisconnected)
 	nh.shutdown.Store(false)
-	nh.aliveTimer = time.NewTicker(time.Second * 10)
+	nh.cfg = DefaultNetConfig()
+	nh.aliveTimer = time.NewTicker(nh.cfg.AliveInterval)
+	nh.requestTimer = time.NewTicker(requestCheck)
 
 	nh.aliveMissed = 0
 }
 
+// SetCapture records all traffic from now on, call it before Run
+func (nh *NetHandler) SetCapture(c *Capture) {
+	nh.capture = c
+}
+
+// SetConfig replaces the defaults from Init, call it before Run
+func (nh *NetHandler) SetConfig(cfg NetConfig) {
+	nh.cfg = cfg
+	nh.aliveTimer.Reset(cfg.AliveInterval)
+}
+
 // Run is the main network thread
 func (nh *NetHandler) Run() {
 	fmt.Println("Network thread starting")
@@ -103,6 +154,11 @@ func (nh *NetHandler) Run() {
 				nh.sendAlive()
 			}
 
+		case <-nh.requestTimer.C:
+			if nh.state.Load() == StateConnected {
+				nh.checkRequests()
+			}
+
 		default:
 			time.Sleep(10 * time.Millisecond)
 		}
@@ -131,6 +187,52 @@ func (nh *NetHandler) sendAlive() {
 	nh.sendMessage(NetMsg{Code: "ALV?"})
 }
 
+func (nh *NetHandler) checkRequests() {
+	now := time.Now()
+
+	nh.pending.Lock()
+	var expired *pendingRequest
+	for i := range nh.pending.requests {
+		if now.After(nh.pending.requests[i].deadline) {
+			expired = &nh.pending.requests[i]
+			break
+		}
+	}
+
+	if expired == nil {
+		nh.pending.Unlock()
+		return
+	}
+
+	// the rest of the answers can't be trusted either once one is missing
+	timeout := NetRequestTimeout{Code: expired.code, Expected: expired.expected}
+	nh.pending.requests = nil
+	nh.pending.Unlock()
+
+	fmt.Printf("No answer to %s, expected one of %v\n", timeout.Code, timeout.Expected)
+	nh.eventChan <- timeout
+	nh.handleConnectionLost()
+}
+
+// resolveRequest drops the oldest request that was waiting for code
+func (nh *NetHandler) resolveRequest(code string) {
+	nh.pending.Lock()
+	defer nh.pending.Unlock()
+
+	for i, req := range nh.pending.requests {
+		if slices.Contains(req.expected, code) {
+			nh.pending.requests = slices.Delete(nh.pending.requests, i, i+1)
+			return
+		}
+	}
+}
+
+func (nh *NetHandler) clearRequests() {
+	nh.pending.Lock()
+	nh.pending.requests = nil
+	nh.pending.Unlock()
+}
+
 func (nh *NetHandler) handleCommand(cmd NetCommand) {
 	switch c := cmd.(type) {
 	case NetConnect:
@@ -182,6 +284,7 @@ func (nh *NetHandler) handleDisconnect() {
 	nh.reconnectData.Unlock()
 
 	nh.disconnectInternal()
+	nh.clearRequests()
 	nh.setState(StateDisconnected)
 	nh.eventChan <- NetDisconnected{}
 }
@@ -226,7 +329,7 @@ func (nh *NetHandler) connectWithRetry(host, port string, isReconnect bool) {
 			nh.reconnectData.attempts = attempt
 			nh.reconnectData.Unlock()
 
-			if attempt > reconnectMax {
+			if attempt > nh.cfg.ReconnectMax {
 				fmt.Println("Reconnection attempts exhausted")
 				nh.disconnectInternal()
 				nh.setState(StateDisconnected)
@@ -236,7 +339,7 @@ func (nh *NetHandler) connectWithRetry(host, port string, isReconnect bool) {
 
 			nh.eventChan <- NetReconnecting{
 				Attempt: attempt,
-				Max:     reconnectMax,
+				Max:     nh.cfg.ReconnectMax,
 			}
 		}
 
@@ -260,7 +363,7 @@ func (nh *NetHandler) connectWithRetry(host, port string, isReconnect bool) {
 
 			nh.aliveMissed = 0
 			nh.aliveReceived = false
-			nh.aliveTimer.Reset(time.Second * 10)
+			nh.aliveTimer.Reset(nh.cfg.AliveInterval)
 
 			go nh.readerLoop(conn)
 			return
@@ -276,7 +379,7 @@ func (nh *NetHandler) connectWithRetry(host, port string, isReconnect bool) {
 		}
 
 		select {
-		case <-time.After(reconnectInt):
+		case <-time.After(nh.cfg.ReconnectInterval):
 			continue
 		case cmd := <-nh.commandChan:
 			switch cmd.(type) {
@@ -311,11 +414,20 @@ func (nh *NetHandler) readerLoop(conn net.Conn) {
 			}
 
 			fmt.Printf("Reader error: %v\n", err)
-			nh.handleConnectionLost()
+
+			// closed on purpose, a new connection may already be running
+			nh.connMtx.RLock()
+			current := nh.conn == conn
+			nh.connMtx.RUnlock()
+
+			if current {
+				nh.handleConnectionLost()
+			}
 			return
 		}
 
 		fmt.Printf("Received %d bytes\n", bytesRead)
+		nh.capture.Record(DirIn, buffer[:bytesRead])
 		nh.processBuffer(buffer[:bytesRead], &parser)
 	}
 }
@@ -334,12 +446,18 @@ func (nh *NetHandler) processBuffer(buffer []byte, parser *Parser) {
 
 		if results.parser_done {
 			fmt.Printf("Parsed message: %s\n", results.code)
+			nh.resolveRequest(results.code)
 
 			switch results.code {
 			case "ALV!":
 				nh.aliveReceived = true
 			case "PING":
 				nh.sendMessage(NetMsg{Code: "PING"})
+			case CodeNickOK, CodeReconnect:
+				// the framing is owned by this thread, so look at the answer
+				// here before passing it on
+				nh.storeHandshake(results.code, results.payload)
+				fallthrough
 			default:
 				nh.eventChan <- NetMessage{
 					Msg: NetMsg{
@@ -358,6 +476,29 @@ func (nh *NetHandler) processBuffer(buffer []byte, parser *Parser) {
 	}
 }
 
+func (nh *NetHandler) storeHandshake(code string, payload string) {
+	var info ServerInfo
+
+	switch code {
+	case CodeNickOK:
+		answer := NickOKMsg{}
+		if err := answer.Decode(payload); err != nil {
+			return
+		}
+		info = negotiate(answer.Version, answer.Capabilities)
+
+	case CodeReconnect:
+		answer := ReconnectMsg{}
+		if err := answer.Decode(payload); err != nil {
+			return
+		}
+		info = negotiate(answer.Version, answer.Capabilities)
+	}
+
+	nh.serverInfo.Store(&info)
+	fmt.Printf("Server protocol version %d, capabilities %v\n", info.Version, info.Capabilities)
+}
+
 func (nh *NetHandler) handleConnectionLost() {
 	currentState := nh.getState()
 	if currentState != StateConnected {
@@ -406,7 +547,17 @@ func (nh *NetHandler) sendMessage(msg NetMsg) error {
 	defer conn.SetWriteDeadline(time.Time{}) // Reset deadline
 
 	data := msg.ToString()
+	if len(msg.Payload) > maxPayloadLen {
+		if !nh.HasCapability(CapExtendedFrames) {
+			return fmt.Errorf("payload of %s too long for the server (%d bytes)", msg.Code, len(msg.Payload))
+		}
+		data = msg.ToExtendedString()
+	}
+
 	_, err := conn.Write([]byte(data))
+	if err == nil {
+		nh.capture.Record(DirOut, []byte(data))
+	}
 
 	if err != nil {
 		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
@@ -432,10 +583,30 @@ func (nh *NetHandler) setConnection(conn net.Conn) {
 	nh.connMtx.Lock()
 	nh.conn = conn
 	nh.connMtx.Unlock()
+
+	// every connection has to negotiate again
+	nh.serverInfo.Store(&ServerInfo{Version: LegacyVersion})
+
+	// answers to requests sent over an old connection are never coming
+	nh.clearRequests()
+}
+
+// ServerInfo returns what the server agreed to in PNOK for the current connection
+func (nh *NetHandler) ServerInfo() ServerInfo {
+	info := nh.serverInfo.Load()
+	if info == nil {
+		return ServerInfo{Version: LegacyVersion}
+	}
+	return *info
+}
+
+func (nh *NetHandler) HasCapability(capability string) bool {
+	return nh.ServerInfo().Has(capability)
 }
 
 func (nh *NetHandler) cleanup() {
 	nh.disconnectInternal()
+	nh.capture.Close()
 	close(nh.eventChan)
 	close(nh.commandChan)
 	close(nh.msgOutChan)
@@ -460,3 +631,37 @@ func (nh *NetHandler) SendNetMsg(msg NetMsg) {
 		fmt.Println("Message channel full")
 	}
 }
+
+// SendMsg encodes a typed message and queues it like SendNetMsg
+func (nh *NetHandler) SendMsg(m Message) error {
+	msg, err := EncodeMsg(m)
+	if err != nil {
+		fmt.Println("Failed to encode message:", err)
+		return err
+	}
+
+	nh.SendNetMsg(msg)
+	return nil
+}
+
+// Request sends m and expects one of expectCodes back within timeout.
+// The answer arrives as a normal NetMessage. If it doesn't, NetRequestTimeout
+// is sent instead and the connection is reset.
+func (nh *NetHandler) Request(m Message, expectCodes []string, timeout time.Duration) error {
+	msg, err := EncodeMsg(m)
+	if err != nil {
+		fmt.Println("Failed to encode message:", err)
+		return err
+	}
+
+	nh.pending.Lock()
+	nh.pending.requests = append(nh.pending.requests, pendingRequest{
+		code:     msg.Code,
+		expected: expectCodes,
+		deadline: time.Now().Add(timeout),
+	})
+	nh.pending.Unlock()
+
+	nh.SendNetMsg(msg)
+	return nil
+}
@@ -0,0 +1,42 @@
+package ups_net
+
+import (
+	"net"
+	"time"
+)
+
+// Probe measures the round trip of a single ALV? without logging in. Both
+// servers answer keepalives in their reader before CONN, so nothing else is
+// sent and the server just sees a short connection.
+func Probe(host, port string, timeout time.Duration) (time.Duration, error) {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	msg, err := EncodeMsg(&AliveRequestMsg{})
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if err := NewFrameWriter(conn).WriteFrame(msg); err != nil {
+		return 0, err
+	}
+
+	// a PING may come first if the server is slow to notice
+	reader := NewFrameReader(conn)
+	for {
+		reply, err := reader.ReadFrame()
+		if err != nil {
+			return 0, err
+		}
+
+		if reply.Code == CodeAliveReply {
+			return time.Since(start), nil
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package ups_net
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// how far ahead an outbound message is looked for before it counts as different
+	replayLookahead = 8
+	// how long inbound traffic waits for the game to send what the capture has first
+	replayStall = 5 * time.Second
+)
+
+// replayed is an inbound entry with the number of outbound messages the
+// capture has before it, the server only answered once it got those
+type replayed struct {
+	CaptureEntry
+	after int
+}
+
+// RunReplay is used instead of Run, it plays a capture back to the game
+// without a server. Inbound traffic goes through processBuffer like real
+// reads, outbound messages are only compared to what the capture has.
+// speed 1 keeps the original timing, 0 feeds everything as soon as the game
+// has caught up.
+func (nh *NetHandler) RunReplay(entries []CaptureEntry, speed float64) {
+	fmt.Printf("Replay thread starting, %d entries\n", len(entries))
+
+	inbound := make([]replayed, 0, len(entries))
+	outbound := make([]NetMsg, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Dir == DirIn {
+			inbound = append(inbound, replayed{entry, len(outbound)})
+			continue
+		}
+
+		msg, err := NewFrameReader(strings.NewReader(string(entry.Data))).ReadFrame()
+		if err != nil {
+			fmt.Printf("Replay: skipping unreadable outbound frame %q: %v\n", entry.Data, err)
+			continue
+		}
+
+		// keepalives come from this thread, the game never sends them
+		if msg.Code != "ALV?" && msg.Code != "PING" {
+			outbound = append(outbound, msg)
+		}
+	}
+
+	parser := Parser{}
+	parser.Init()
+
+	// nothing is fed until the game connects, like with a real server
+	var lastFed, waiting time.Time
+	connected := false
+	fed, sent := 0, 0
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !nh.shutdown.Load() {
+		select {
+		case cmd := <-nh.commandChan:
+			switch cmd.(type) {
+			case NetConnect:
+				if connected || fed > 0 {
+					fmt.Println("Replay: the capture can only be played once")
+					continue
+				}
+
+				connected = true
+				lastFed = time.Now()
+				nh.setState(StateConnected)
+				nh.eventChan <- NetConnecting{}
+				nh.eventChan <- NetConnected{}
+
+			case NetDisconnect:
+				connected = false
+				nh.userDisconnect.Store(true)
+				nh.setState(StateDisconnected)
+				nh.eventChan <- NetDisconnected{}
+
+			case NetShutdown:
+				nh.shutdown.Store(true)
+			}
+
+		case msg := <-nh.msgOutChan:
+			sent = compareReplayed(msg, outbound, sent)
+
+		case <-ticker.C:
+			if !connected || nh.getState() != StateConnected || fed == len(inbound) {
+				continue
+			}
+
+			next := inbound[fed]
+			prev := next.Time
+			if fed > 0 {
+				prev = inbound[fed-1].Time
+			}
+			if time.Now().Before(replayAt(lastFed, prev, next.Time, speed)) {
+				continue
+			}
+
+			if sent < next.after {
+				if waiting.IsZero() {
+					waiting = time.Now()
+				}
+				if time.Since(waiting) < replayStall {
+					continue
+				}
+
+				fmt.Printf("Replay: game never sent %s, feeding anyway\n", outbound[sent].Code)
+				sent = next.after
+			}
+
+			waiting = time.Time{}
+			lastFed = time.Now()
+			fmt.Printf("Replay: feeding %d bytes\n", len(next.Data))
+			nh.processBuffer(next.Data, &parser)
+			fed++
+
+			if fed == len(inbound) {
+				fmt.Printf("Replay finished, %d outbound messages weren't sent again\n", len(outbound)-sent)
+			}
+		}
+	}
+
+	fmt.Println("Replay thread shutting down")
+	nh.cleanup()
+}
+
+// replayAt is when an entry is due. The gaps are kept relative to the
+// previous entry, after a stall everything would be due at once otherwise.
+func replayAt(lastFed, prev, at time.Time, speed float64) time.Time {
+	if speed <= 0 {
+		return lastFed
+	}
+	return lastFed.Add(time.Duration(float64(at.Sub(prev)) / speed))
+}
+
+// compareReplayed returns how much of outbound the game has sent so far.
+// A different message is usually where a desync starts, but the handshake is
+// racy even live, so a few extra or missing messages are skipped over
+// instead of shifting every comparison after them.
+func compareReplayed(msg NetMsg, outbound []NetMsg, sent int) int {
+	if sent == len(outbound) {
+		fmt.Printf("Replay: game sent %s %q after the capture ended\n", msg.Code, msg.Payload)
+		return sent
+	}
+
+	for i, expected := range outbound[sent:min(len(outbound), sent+replayLookahead)] {
+		if expected.Code != msg.Code || expected.Payload != msg.Payload {
+			continue
+		}
+
+		for _, missed := range outbound[sent : sent+i] {
+			fmt.Printf("Replay: game didn't send %s %q\n", missed.Code, missed.Payload)
+		}
+		return sent + i + 1
+	}
+
+	expected := outbound[sent]
+	fmt.Printf("Replay: game sent %s %q, capture has %s %q\n", msg.Code, msg.Payload, expected.Code, expected.Payload)
+	return sent
+}
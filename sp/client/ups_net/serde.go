@@ -38,6 +38,8 @@ const (
 	Type
 	Code
 	Size
+	ExtSizeLen
+	ExtSize
 	Payload
 	Endline
 )
@@ -51,8 +53,13 @@ const (
 const (
 	PayloadMsg   MsgType = 'P'
 	NoPayloadMsg MsgType = 'N'
+	// Same as 'P' but the length is a VarInt, only used once negotiated in CONN
+	ExtendedMsg MsgType = 'X'
 )
 
+// Upper bound for extended frames so a broken length can't eat all memory
+const MAX_EXT_PAYLOAD_LEN uint64 = 16 * 1024 * 1024
+
 type ParseResults struct {
 	Error       bool
 	parser_done bool
@@ -68,6 +75,7 @@ type Parser struct {
 	phase       MainPart
 	msg_type    MsgType
 	size_index  uint64
+	size_digits uint64
 	code_index  uint64
 	payload_len uint64
 }
@@ -78,6 +86,7 @@ func (p *Parser) Init() {
 	p.phase = Magic_1
 	p.msg_type = 'N'
 	p.size_index = 0
+	p.size_digits = 0
 	p.code_index = 0
 	p.payload_len = 0
 }
@@ -88,6 +97,7 @@ func (p *Parser) ResetParser() {
 	p.phase = Magic_1
 	p.msg_type = 'N'
 	p.size_index = 0
+	p.size_digits = 0
 	p.code_index = 0
 	p.payload_len = 0
 }
@@ -116,12 +126,8 @@ func (p *Parser) ParseByte(char byte) ParserState {
 		p.phase = Type
 
 	case Type:
-		if char == 'N' || char == 'P' {
-			if char == 'N' {
-				p.msg_type = NoPayloadMsg
-			} else {
-				p.msg_type = PayloadMsg
-			}
+		if char == 'N' || char == 'P' || char == 'X' {
+			p.msg_type = MsgType(char)
 
 			p.phase = Code
 			return OK
@@ -135,9 +141,12 @@ func (p *Parser) ParseByte(char byte) ParserState {
 		p.code_index++
 
 		if p.code_index >= MSG_CODE_SIZE {
-			if p.msg_type == NoPayloadMsg {
+			switch p.msg_type {
+			case NoPayloadMsg:
 				p.phase = Endline
-			} else {
+			case ExtendedMsg:
+				p.phase = ExtSizeLen
+			default:
 				p.phase = Size
 			}
 		}
@@ -155,6 +164,48 @@ func (p *Parser) ParseByte(char byte) ParserState {
 		p.payload_len = p.payload_len*10 + (uint64(char) - '0')
 		p.size_index++
 
+	case ExtSizeLen:
+		if char < '0' || char > '9' {
+			fmt.Println("Non numeric character in extended size length", fmt.Sprintf("%d", char))
+			return Invalid
+		}
+
+		p.size_digits = p.size_digits*10 + (uint64(char) - '0')
+		p.size_index++
+
+		if p.size_index >= 2 {
+			if p.size_digits == 0 {
+				fmt.Println("Extended size has no digits")
+				return Invalid
+			}
+
+			p.size_index = 0
+			p.phase = ExtSize
+		}
+
+	case ExtSize:
+		if char < '0' || char > '9' {
+			fmt.Println("Non numeric character in extended size", fmt.Sprintf("%d", char))
+			return Invalid
+		}
+
+		p.payload_len = p.payload_len*10 + (uint64(char) - '0')
+		p.size_index++
+
+		if p.payload_len > MAX_EXT_PAYLOAD_LEN {
+			fmt.Println("Extended payload too long")
+			return Invalid
+		}
+
+		if p.size_index >= p.size_digits {
+			if p.payload_len == 0 {
+				fmt.Println("Extended message with empty payload")
+				return Invalid
+			}
+
+			p.phase = Payload
+		}
+
 	case Payload:
 		p.payload.WriteByte(char)
 
@@ -208,7 +259,7 @@ func (p *Parser) ParseBytes(bytes []byte) ParseResults {
 	if res.parser_done {
 		res.msg_type = p.msg_type
 		res.code = p.code.String()
-		if p.msg_type == PayloadMsg {
+		if p.msg_type == PayloadMsg || p.msg_type == ExtendedMsg {
 			res.payload = p.GetPayload()
 		}
 	}
@@ -431,3 +482,23 @@ func (msg *NetMsg) ToString() string {
 	builder.WriteByte('\n')
 	return builder.String()
 }
+
+// same as ToString, but a payload is always framed with a VarInt length.
+// Only valid once both sides agreed on extended frames
+func (msg *NetMsg) ToExtendedString() string {
+	if len(msg.Payload) == 0 {
+		return msg.ToString()
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(magicStr)
+	builder.WriteByte(byte(ExtendedMsg))
+	builder.WriteString(msg.Code)
+
+	len_str, _ := WriteVarInt(len(msg.Payload))
+	builder.WriteString(len_str)
+	builder.WriteString(msg.Payload)
+
+	builder.WriteByte('\n')
+	return builder.String()
+}
@@ -32,7 +32,16 @@ func (b *ButtonComponent) Calculate(bounds rl.Rectangle) {
 }
 
 func (b *ButtonComponent) Draw(eventChannel chan<- UIEvent) {
-	if rg.Button(b.bounds, b.Text) {
+	Focus.register(b.ID, false)
+	clicked := rg.Button(b.bounds, b.Text)
+
+	// Enter works like a click on the focused button
+	if Focus.IsFocused(b.ID) {
+		drawFocusRing(b.bounds)
+		clicked = clicked || rl.IsKeyPressed(rl.KeyEnter)
+	}
+
+	if clicked {
 		eventChannel <- UIEvent{SourceID: b.ID, Type: EventClick}
 	}
 }
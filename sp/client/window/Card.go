@@ -0,0 +1,204 @@
+package window
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// CardBack is the ID of a card nobody is supposed to see
+const CardBack = -1
+
+// card IDs are suit*13 + rank, suits in the order hearts, diamonds, clubs, spades
+const (
+	suitHearts = iota
+	suitDiamonds
+	suitClubs
+	suitSpades
+)
+
+var cardRanks = []string{"2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A"}
+
+// pip positions of the number cards in the middle of the card, 0-1 on both axes
+var cardPips = map[int][]rl.Vector2{
+	2:  {{X: .5, Y: 0}, {X: .5, Y: 1}},
+	3:  {{X: .5, Y: 0}, {X: .5, Y: .5}, {X: .5, Y: 1}},
+	4:  {{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}},
+	5:  {{X: 0, Y: 0}, {X: 1, Y: 0}, {X: .5, Y: .5}, {X: 0, Y: 1}, {X: 1, Y: 1}},
+	6:  {{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: .5}, {X: 1, Y: .5}, {X: 0, Y: 1}, {X: 1, Y: 1}},
+	7:  {{X: 0, Y: 0}, {X: 1, Y: 0}, {X: .5, Y: .25}, {X: 0, Y: .5}, {X: 1, Y: .5}, {X: 0, Y: 1}, {X: 1, Y: 1}},
+	8:  {{X: 0, Y: 0}, {X: 1, Y: 0}, {X: .5, Y: .25}, {X: 0, Y: .5}, {X: 1, Y: .5}, {X: .5, Y: .75}, {X: 0, Y: 1}, {X: 1, Y: 1}},
+	9:  {{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1. / 3}, {X: 1, Y: 1. / 3}, {X: .5, Y: .5}, {X: 0, Y: 2. / 3}, {X: 1, Y: 2. / 3}, {X: 0, Y: 1}, {X: 1, Y: 1}},
+	10: {{X: 0, Y: 0}, {X: 1, Y: 0}, {X: .5, Y: 1. / 6}, {X: 0, Y: 1. / 3}, {X: 1, Y: 1. / 3}, {X: 0, Y: 2. / 3}, {X: 1, Y: 2. / 3}, {X: .5, Y: 5. / 6}, {X: 0, Y: 1}, {X: 1, Y: 1}},
+}
+
+const (
+	cardAspect    = 5.0 / 7.0 // width to height, like a poker card
+	cardSmallH    = 60        // below this only the corner and one pip are drawn
+	cardRoundness = 0.15
+)
+
+// CardComponent draws a playing card from its ID, it keeps the card shape
+// and centers itself in whatever bounds it gets
+type CardComponent struct {
+	bounds rl.Rectangle
+	card   rl.Rectangle
+	ID     int
+	// drawn with a gold edge, eg. the own hand
+	Highlight bool
+}
+
+func NewCardComponent(id int) *CardComponent {
+	return &CardComponent{ID: id}
+}
+
+func (c *CardComponent) Calculate(bounds rl.Rectangle) {
+	c.bounds = bounds
+
+	w, h := bounds.Width, bounds.Height
+	if w/h > cardAspect {
+		w = h * cardAspect
+	} else {
+		h = w / cardAspect
+	}
+	c.card = rl.Rectangle{X: bounds.X + (bounds.Width-w)/2, Y: bounds.Y + (bounds.Height-h)/2, Width: w, Height: h}
+}
+
+func (c *CardComponent) Draw(eventChannel chan<- UIEvent) {
+	if c.card.Width <= 0 || c.card.Height <= 0 {
+		return
+	}
+
+	if c.ID < 0 || c.ID > 51 {
+		c.drawBack()
+	} else {
+		c.drawFace()
+	}
+
+	edge := rl.DarkGray
+	thick := float32(1)
+	if c.Highlight {
+		edge, thick = rl.Gold, 3
+	}
+	rl.DrawRectangleRoundedLinesEx(c.card, cardRoundness, 6, thick, edge)
+}
+
+func (c *CardComponent) drawBack() {
+	rl.DrawRectangleRounded(c.card, cardRoundness, 6, rl.NewColor(150, 20, 30, 255))
+
+	inset := c.card.Width * 0.1
+	inner := rl.Rectangle{X: c.card.X + inset, Y: c.card.Y + inset, Width: c.card.Width - inset*2, Height: c.card.Height - inset*2}
+	rl.DrawRectangleLinesEx(inner, max(1, c.card.Width*0.02), rl.RayWhite)
+
+	// a diagonal lattice, clipped to the inner frame
+	rl.BeginScissorMode(int32(inner.X), int32(inner.Y), int32(inner.Width), int32(inner.Height))
+	step := max(4, inner.Width/5)
+	pattern := rl.NewColor(230, 120, 120, 255)
+	for off := -inner.Height; off < inner.Width; off += step {
+		rl.DrawLineV(rl.Vector2{X: inner.X + off, Y: inner.Y}, rl.Vector2{X: inner.X + off + inner.Height, Y: inner.Y + inner.Height}, pattern)
+		rl.DrawLineV(rl.Vector2{X: inner.X + off + inner.Height, Y: inner.Y}, rl.Vector2{X: inner.X + off, Y: inner.Y + inner.Height}, pattern)
+	}
+	rl.EndScissorMode()
+}
+
+func (c *CardComponent) drawFace() {
+	suit, rank := c.ID/13, c.ID%13
+	color := rl.Black
+	if suit == suitHearts || suit == suitDiamonds {
+		color = rl.NewColor(200, 20, 30, 255)
+	}
+
+	rl.DrawRectangleRounded(c.card, cardRoundness, 6, rl.RayWhite)
+
+	// rank and a small pip in the top left corner, the same upside down at the bottom right
+	font := rl.GetFontDefault()
+	fontSize := max(10, c.card.Height*0.13)
+	spacing := fontSize / 10
+	label := cardRanks[rank]
+	labelW := rl.MeasureTextEx(font, label, fontSize, spacing).X
+	pad := c.card.Width * 0.07
+	cornerPip := fontSize * 0.7
+
+	rl.DrawTextEx(font, label, rl.Vector2{X: c.card.X + pad, Y: c.card.Y + pad}, fontSize, spacing, color)
+
+	// no room for more on a small card, the rank and one big pip have to do
+	if c.card.Height < cardSmallH {
+		drawSuit(suit, rl.Vector2{X: c.card.X + c.card.Width*0.55, Y: c.card.Y + c.card.Height*0.65}, c.card.Width*0.5, color)
+		return
+	}
+
+	rl.DrawTextPro(font, label, rl.Vector2{X: c.card.X + c.card.Width - pad, Y: c.card.Y + c.card.Height - pad}, rl.Vector2{}, 180, fontSize, spacing, color)
+	drawSuit(suit, rl.Vector2{X: c.card.X + pad + labelW/2, Y: c.card.Y + pad + fontSize + cornerPip*0.6}, cornerPip, color)
+	drawSuit(suit, rl.Vector2{X: c.card.X + c.card.Width - pad - labelW/2, Y: c.card.Y + c.card.Height - pad - fontSize - cornerPip*0.6}, cornerPip, color)
+
+	// the middle, between the corners
+	inner := rl.Rectangle{
+		X:      c.card.X + c.card.Width*0.32,
+		Y:      c.card.Y + c.card.Height*0.22,
+		Width:  c.card.Width * 0.36,
+		Height: c.card.Height * 0.56,
+	}
+	center := rl.Vector2{X: inner.X + inner.Width/2, Y: inner.Y + inner.Height/2}
+
+	switch label {
+	case "A":
+		drawSuit(suit, center, c.card.Width*0.4, color)
+
+	case "J", "Q", "K":
+		rl.DrawRectangleLinesEx(inner, max(1, c.card.Width*0.015), color)
+		faceSize := inner.Height * 0.4
+		faceW := rl.MeasureTextEx(font, label, faceSize, faceSize/10).X
+		rl.DrawTextEx(font, label, rl.Vector2{X: center.X - faceW/2, Y: inner.Y + inner.Height*0.12}, faceSize, faceSize/10, color)
+		drawSuit(suit, rl.Vector2{X: center.X, Y: inner.Y + inner.Height*0.72}, inner.Width*0.45, color)
+
+	default:
+		pipSize := c.card.Width * 0.15
+		for _, pip := range cardPips[rank+2] {
+			drawSuit(suit, rl.Vector2{X: inner.X + pip.X*inner.Width, Y: inner.Y + pip.Y*inner.Height}, pipSize, color)
+		}
+	}
+}
+
+// drawSuit draws a suit symbol about size wide around center. Triangles go
+// counter clockwise on screen, raylib skips them otherwise.
+func drawSuit(suit int, center rl.Vector2, size float32, color rl.Color) {
+	cx, cy := center.X, center.Y
+	r := size * 0.26
+
+	switch suit {
+	case suitHearts:
+		rl.DrawCircleV(rl.Vector2{X: cx - r, Y: cy - size*0.15}, r, color)
+		rl.DrawCircleV(rl.Vector2{X: cx + r, Y: cy - size*0.15}, r, color)
+		rl.DrawTriangle(rl.Vector2{X: cx - 2*r, Y: cy - size*0.1}, rl.Vector2{X: cx, Y: cy + size*0.45}, rl.Vector2{X: cx + 2*r, Y: cy - size*0.1}, color)
+
+	case suitDiamonds:
+		top := rl.Vector2{X: cx, Y: cy - size*0.5}
+		left := rl.Vector2{X: cx - size*0.38, Y: cy}
+		right := rl.Vector2{X: cx + size*0.38, Y: cy}
+		bottom := rl.Vector2{X: cx, Y: cy + size*0.5}
+		rl.DrawTriangle(top, left, right, color)
+		rl.DrawTriangle(left, bottom, right, color)
+
+	case suitClubs:
+		rl.DrawCircleV(rl.Vector2{X: cx, Y: cy - size*0.22}, r, color)
+		rl.DrawCircleV(rl.Vector2{X: cx - size*0.24, Y: cy + size*0.08}, r, color)
+		rl.DrawCircleV(rl.Vector2{X: cx + size*0.24, Y: cy + size*0.08}, r, color)
+		drawStem(cx, cy, size, color)
+
+	case suitSpades:
+		rl.DrawTriangle(rl.Vector2{X: cx, Y: cy - size*0.5}, rl.Vector2{X: cx - 2*r, Y: cy + size*0.05}, rl.Vector2{X: cx + 2*r, Y: cy + size*0.05}, color)
+		rl.DrawCircleV(rl.Vector2{X: cx - r, Y: cy + size*0.1}, r, color)
+		rl.DrawCircleV(rl.Vector2{X: cx + r, Y: cy + size*0.1}, r, color)
+		drawStem(cx, cy, size, color)
+	}
+}
+
+func drawStem(cx, cy, size float32, color rl.Color) {
+	rl.DrawTriangle(rl.Vector2{X: cx, Y: cy}, rl.Vector2{X: cx - size*0.16, Y: cy + size*0.5}, rl.Vector2{X: cx + size*0.16, Y: cy + size*0.5}, color)
+}
+
+func (c *CardComponent) GetBounds() rl.Rectangle {
+	return c.bounds
+}
+
+func (c *CardComponent) Rebuild(old RGComponent) {
+	/* noop since this is true leaf node*/
+}
@@ -0,0 +1,162 @@
+package window
+
+import (
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+type chatLine struct {
+	text   string
+	notice bool
+}
+
+// ChatComponent is a chat log with an input line under it. Enter in the box
+// and the Send button both send an EventClick with the component's ID.
+type ChatComponent struct {
+	bounds  rl.Rectangle
+	logArea rl.Rectangle
+	ID      string
+
+	lines   []chatLine
+	wrapped []chatLine
+
+	input *TextBoxComponent
+	send  *ButtonComponent
+
+	scroll float32
+	// stick to the newest line until the user scrolls up
+	follow bool
+}
+
+const (
+	chatFont    = 14
+	chatLineH   = 18
+	chatPadding = 6
+	chatInputH  = 30
+	chatSendW   = 60
+	chatWheel   = 3 * chatLineH
+)
+
+func NewChatComponent(id string, input *string, maxChars int) *ChatComponent {
+	return &ChatComponent{
+		ID:     id,
+		input:  NewTextBoxComponent(id+"_Input", input, maxChars),
+		send:   NewButtonComponent(id, "Send", chatSendW, chatInputH),
+		follow: true,
+	}
+}
+
+func (c *ChatComponent) AddLine(nick, text string) {
+	c.lines = append(c.lines, chatLine{text: nick + ": " + text})
+}
+
+// AddNotice is a line from the client itself, eg. a message that didn't go through
+func (c *ChatComponent) AddNotice(text string) {
+	c.lines = append(c.lines, chatLine{text: text, notice: true})
+}
+
+// wrapText breaks on spaces, words too long for a line are cut wherever they end up
+func wrapText(text string, width float32) []string {
+	out := make([]string, 0, 1)
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if float32(rl.MeasureText(candidate, chatFont)) <= width {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			out = append(out, line)
+		}
+
+		line = ""
+		for _, r := range word {
+			if line != "" && float32(rl.MeasureText(line+string(r), chatFont)) > width {
+				out = append(out, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+
+	return append(out, line)
+}
+
+func (c *ChatComponent) maxScroll() float32 {
+	return max(0, float32(len(c.wrapped)*chatLineH+chatPadding*2)-c.logArea.Height)
+}
+
+func (c *ChatComponent) Calculate(bounds rl.Rectangle) {
+	c.bounds = bounds
+	c.logArea = rl.Rectangle{X: bounds.X, Y: bounds.Y, Width: bounds.Width, Height: bounds.Height - chatInputH - chatPadding}
+
+	inputY := bounds.Y + bounds.Height - chatInputH
+	c.input.Calculate(rl.Rectangle{X: bounds.X, Y: inputY, Width: bounds.Width - chatSendW - chatPadding, Height: chatInputH})
+	c.send.Calculate(rl.Rectangle{X: bounds.X + bounds.Width - chatSendW, Y: inputY, Width: chatSendW, Height: chatInputH})
+
+	c.wrapped = c.wrapped[:0]
+	for _, line := range c.lines {
+		for _, part := range wrapText(line.text, c.logArea.Width-chatPadding*2) {
+			c.wrapped = append(c.wrapped, chatLine{text: part, notice: line.notice})
+		}
+	}
+
+	if c.follow {
+		c.scroll = c.maxScroll()
+	}
+}
+
+func (c *ChatComponent) Draw(eventChannel chan<- UIEvent) {
+	if rl.CheckCollisionPointRec(rl.GetMousePosition(), c.logArea) {
+		if wheel := rl.GetMouseWheelMove(); wheel != 0 {
+			c.scroll = min(max(c.scroll-wheel*chatWheel, 0), c.maxScroll())
+			c.follow = c.scroll >= c.maxScroll()
+		}
+	}
+
+	rl.DrawRectangleRec(c.logArea, rl.NewColor(20, 20, 20, 200))
+	rl.DrawRectangleLinesEx(c.logArea, 1, rl.Gray)
+
+	rl.BeginScissorMode(int32(c.logArea.X), int32(c.logArea.Y), int32(c.logArea.Width), int32(c.logArea.Height))
+	y := c.logArea.Y + chatPadding - c.scroll
+	for _, line := range c.wrapped {
+		if y+chatLineH >= c.logArea.Y && y <= c.logArea.Y+c.logArea.Height {
+			color := rl.RayWhite
+			if line.notice {
+				color = rl.Gray
+			}
+			rl.DrawText(line.text, int32(c.logArea.X+chatPadding), int32(y), chatFont, color)
+		}
+		y += chatLineH
+	}
+	rl.EndScissorMode()
+
+	c.input.Draw(eventChannel)
+	c.send.Draw(eventChannel)
+
+	if Focus.IsFocused(c.input.ID) && rl.IsKeyPressed(rl.KeyEnter) {
+		eventChannel <- UIEvent{SourceID: c.ID, Type: EventClick}
+	}
+}
+
+func (c *ChatComponent) GetBounds() rl.Rectangle {
+	return c.bounds
+}
+
+func (c *ChatComponent) Rebuild(old RGComponent) {
+	if old == nil {
+		return
+	}
+
+	if oldC, ok := old.(*ChatComponent); ok {
+		c.scroll = oldC.scroll
+		c.follow = oldC.follow
+	}
+}
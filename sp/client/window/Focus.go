@@ -0,0 +1,85 @@
+package window
+
+import (
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+type focusEntry struct {
+	id   string
+	text bool // text boxes swallow the keyboard, hotkeys stay off while they have focus
+}
+
+// FocusManager tracks keyboard focus by component ID. The UI is rebuilt every
+// frame, so components register themselves while drawing and Tab walks the
+// order of the previous frame.
+type FocusManager struct {
+	order   []focusEntry // this frame, in draw order
+	prev    []focusEntry
+	focused string
+}
+
+// Focus is shared by every component, like raygui's own state
+var Focus = &FocusManager{}
+
+// Update runs once per frame before anything is drawn
+func (f *FocusManager) Update() {
+	f.prev, f.order = f.order, f.prev[:0]
+
+	idx := slices.IndexFunc(f.prev, func(e focusEntry) bool { return e.id == f.focused })
+	if idx < 0 {
+		// the component is gone, eg. the screen changed
+		f.focused = ""
+	}
+
+	if !rl.IsKeyPressed(rl.KeyTab) || len(f.prev) == 0 {
+		return
+	}
+
+	back := rl.IsKeyDown(rl.KeyLeftShift) || rl.IsKeyDown(rl.KeyRightShift)
+	switch {
+	case idx < 0 && back:
+		idx = len(f.prev) - 1
+	case idx < 0:
+		idx = 0
+	case back:
+		idx = (idx - 1 + len(f.prev)) % len(f.prev)
+	default:
+		idx = (idx + 1) % len(f.prev)
+	}
+	f.focused = f.prev[idx].id
+}
+
+func (f *FocusManager) register(id string, text bool) {
+	f.order = append(f.order, focusEntry{id: id, text: text})
+}
+
+func (f *FocusManager) IsFocused(id string) bool {
+	return id != "" && f.focused == id
+}
+
+func (f *FocusManager) Set(id string) {
+	f.focused = id
+}
+
+// Release drops the focus if id has it
+func (f *FocusManager) Release(id string) {
+	if f.focused == id {
+		f.focused = ""
+	}
+}
+
+// Has is true if the component was drawn this frame
+func (f *FocusManager) Has(id string) bool {
+	return slices.ContainsFunc(f.order, func(e focusEntry) bool { return e.id == id })
+}
+
+// Typing is true while a text box has the focus
+func (f *FocusManager) Typing() bool {
+	return slices.ContainsFunc(f.order, func(e focusEntry) bool { return e.text && e.id == f.focused })
+}
+
+func drawFocusRing(bounds rl.Rectangle) {
+	rl.DrawRectangleLinesEx(bounds, 2, rl.Gold)
+}
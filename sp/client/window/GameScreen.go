@@ -6,77 +6,96 @@ import (
 
 type GameScreen struct {
 	*VStack
-	riverBar        *HStack
-	playerBar       *HStack
-	actionBar       *HStack
-	otherPlayersBar *HStack
-	potDisplay      RGComponent
+	table      *TableLayout
+	riverBar   *HStack
+	playerBar  *HStack
+	actionBar  *HStack
+	potDisplay RGComponent
+	history    RGComponent
+	chat       RGComponent
 }
 
 func NewGameScreen(padding float32) *GameScreen {
-	return &GameScreen{
-		VStack:          NewVStack(padding),
-		riverBar:        NewHStack(padding),
-		playerBar:       NewHStack(padding),
-		actionBar:       NewHStack(padding),
-		otherPlayersBar: NewHStack(padding),
+	gs := &GameScreen{
+		VStack:    NewVStack(padding),
+		riverBar:  NewHStack(padding),
+		playerBar: NewHStack(padding),
+		actionBar: NewHStack(padding),
 	}
+	gs.SetTable(NewTableLayout(0, -1))
+	return gs
 }
 
 // helpers for gamescreen building
-func (gs *GameScreen) AddRiverCard(card RGComponent)     { gs.riverBar.AddChild(card) }
-func (gs *GameScreen) ResetRiver()                       { gs.riverBar = NewHStack(gs.padding) }
-func (gs *GameScreen) AddPlayerCard(card RGComponent)    { gs.playerBar.AddChild(card) } // Your hand
-func (gs *GameScreen) AddActionButton(btn RGComponent)   { gs.actionBar.AddChild(btn) }
-func (gs *GameScreen) ResetOtherPlayers()                { gs.otherPlayersBar = NewHStack(gs.padding) }
-func (gs *GameScreen) AddOtherPlayer(player RGComponent) { gs.otherPlayersBar.AddChild(player) }
-func (gs *GameScreen) SetPotDisplay(pot RGComponent)     { gs.potDisplay = pot }
+func (gs *GameScreen) AddRiverCard(card RGComponent)   { gs.riverBar.AddChild(card) }
+func (gs *GameScreen) ResetRiver()                     { gs.riverBar = NewHStack(gs.padding) }
+func (gs *GameScreen) AddPlayerCard(card RGComponent)  { gs.playerBar.AddChild(card) } // Your hand
+func (gs *GameScreen) AddActionButton(btn RGComponent) { gs.actionBar.AddChild(btn) }
+func (gs *GameScreen) SetPotDisplay(pot RGComponent)   { gs.potDisplay = pot }
+func (gs *GameScreen) SetHistory(history RGComponent)  { gs.history = history }
+func (gs *GameScreen) SetChat(chat RGComponent)        { gs.chat = chat }
+
+// SetTable takes the seats of the others, the own hand goes to its bottom
+func (gs *GameScreen) SetTable(table *TableLayout) {
+	gs.table = table
+	gs.table.SetLocal(gs.playerBar)
+}
 
 func (gs *GameScreen) Calculate(bounds rl.Rectangle) {
 	gs.bounds = bounds
 	padding := gs.padding
 
-	const potH = 0.13
-	const riverH = 0.15
-	const oppH = 0.35
-	const handH = 0.10
-	const actionH = 0.12
+	// history and chat share a column on the right, the table gets the rest
+	if gs.history != nil || gs.chat != nil {
+		const sideW = 0.25
+
+		w := bounds.Width * sideW
+		side := rl.Rectangle{X: bounds.X + bounds.Width - w, Y: bounds.Y + padding, Width: w - padding, Height: bounds.Height - padding*2}
+
+		switch {
+		case gs.history != nil && gs.chat != nil:
+			historyH := (side.Height - padding) / 2
+			gs.history.Calculate(rl.Rectangle{X: side.X, Y: side.Y, Width: side.Width, Height: historyH})
+			gs.chat.Calculate(rl.Rectangle{X: side.X, Y: side.Y + historyH + padding, Width: side.Width, Height: side.Height - historyH - padding})
+		case gs.history != nil:
+			gs.history.Calculate(side)
+		default:
+			gs.chat.Calculate(side)
+		}
+		bounds.Width -= w
+	}
 
-	totalPad := padding * 6
-	availH := bounds.Height - totalPad
+	// the table takes everything over the action bar
+	const actionH = 0.12
 
-	y := bounds.Y + padding
+	actionBarH := (bounds.Height - padding*3) * actionH
+	tableBounds := rl.Rectangle{X: bounds.X + padding, Y: bounds.Y + padding, Width: bounds.Width - padding*2, Height: bounds.Height - actionBarH - padding*3}
+	gs.table.Calculate(tableBounds)
 
+	// pot over the board in the middle of the felt
+	center := gs.table.CenterBounds()
+	potH := center.Height * 0.4
 	if gs.potDisplay != nil {
-		h := availH * potH
-		gs.potDisplay.Calculate(rl.Rectangle{X: bounds.X + padding, Y: y, Width: bounds.Width - padding*2, Height: h})
-		y += h + padding
+		gs.potDisplay.Calculate(rl.Rectangle{X: center.X, Y: center.Y, Width: center.Width, Height: potH})
 	}
+	gs.riverBar.Calculate(rl.Rectangle{X: center.X, Y: center.Y + potH + padding, Width: center.Width, Height: center.Height - potH - padding})
 
-	actualRiverH := availH * riverH
-	gs.riverBar.Calculate(rl.Rectangle{X: bounds.X + padding, Y: y, Width: bounds.Width - padding*2, Height: actualRiverH})
-	y += actualRiverH + padding
-
-	actualOtherPlayersH := availH * oppH
-	gs.otherPlayersBar.Calculate(rl.Rectangle{X: bounds.X + padding, Y: y, Width: bounds.Width - padding*2, Height: actualOtherPlayersH})
-	y += actualOtherPlayersH + padding
-
-	actualHandH := availH * handH
-	gs.playerBar.Calculate(rl.Rectangle{X: bounds.X + padding, Y: y, Width: bounds.Width - padding*2, Height: actualHandH})
-	y += actualHandH + padding
-
-	actualActionH := availH * actionH
-	gs.actionBar.Calculate(rl.Rectangle{X: bounds.X + padding, Y: y, Width: bounds.Width - padding*2, Height: actualActionH})
+	gs.actionBar.Calculate(rl.Rectangle{X: bounds.X + padding, Y: tableBounds.Y + tableBounds.Height + padding, Width: bounds.Width - padding*2, Height: actionBarH})
 }
 
 func (gs *GameScreen) Draw(eventChannel chan<- UIEvent) {
+	gs.table.Draw(eventChannel)
 	if gs.potDisplay != nil {
 		gs.potDisplay.Draw(eventChannel)
 	}
 	gs.riverBar.Draw(eventChannel)
-	gs.otherPlayersBar.Draw(eventChannel)
-	gs.playerBar.Draw(eventChannel)
 	gs.actionBar.Draw(eventChannel)
+	if gs.history != nil {
+		gs.history.Draw(eventChannel)
+	}
+	if gs.chat != nil {
+		gs.chat.Draw(eventChannel)
+	}
 }
 
 func (gs *GameScreen) GetBounds() rl.Rectangle { return gs.bounds }
@@ -88,5 +107,11 @@ func (gs *GameScreen) Rebuild(old RGComponent) {
 
 	if oldGS, ok := old.(*GameScreen); ok {
 		gs.actionBar.Rebuild(oldGS.actionBar)
+		if gs.history != nil && oldGS.history != nil {
+			gs.history.Rebuild(oldGS.history)
+		}
+		if gs.chat != nil && oldGS.chat != nil {
+			gs.chat.Rebuild(oldGS.chat)
+		}
 	}
 }
@@ -0,0 +1,94 @@
+package window
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+type historyLine struct {
+	text  string
+	level int
+}
+
+// HistoryComponent is a scrollable log, level 0 lines are headers and
+// every level below is indented a bit more
+type HistoryComponent struct {
+	bounds rl.Rectangle
+	lines  []historyLine
+
+	scroll float32
+	// stick to the newest line until the user scrolls up
+	follow bool
+}
+
+const (
+	historyFont    = 14
+	historyLineH   = 18
+	historyIndent  = 12
+	historyPadding = 6
+	historyWheel   = 3 * historyLineH
+)
+
+var historyColors = []rl.Color{rl.Gold, rl.SkyBlue, rl.RayWhite}
+
+func NewHistoryComponent() *HistoryComponent {
+	return &HistoryComponent{follow: true}
+}
+
+func (h *HistoryComponent) AddLine(text string, level int) {
+	h.lines = append(h.lines, historyLine{text: text, level: level})
+}
+
+func (h *HistoryComponent) contentHeight() float32 {
+	return float32(len(h.lines)*historyLineH + historyPadding*2)
+}
+
+func (h *HistoryComponent) maxScroll() float32 {
+	return max(0, h.contentHeight()-h.bounds.Height)
+}
+
+func (h *HistoryComponent) Calculate(bounds rl.Rectangle) {
+	h.bounds = bounds
+	if h.follow {
+		h.scroll = h.maxScroll()
+	}
+}
+
+func (h *HistoryComponent) Draw(eventChannel chan<- UIEvent) {
+	if rl.CheckCollisionPointRec(rl.GetMousePosition(), h.bounds) {
+		if wheel := rl.GetMouseWheelMove(); wheel != 0 {
+			h.scroll = min(max(h.scroll-wheel*historyWheel, 0), h.maxScroll())
+			h.follow = h.scroll >= h.maxScroll()
+		}
+	}
+
+	rl.DrawRectangleRec(h.bounds, rl.NewColor(20, 20, 20, 200))
+	rl.DrawRectangleLinesEx(h.bounds, 1, rl.Gray)
+
+	rl.BeginScissorMode(int32(h.bounds.X), int32(h.bounds.Y), int32(h.bounds.Width), int32(h.bounds.Height))
+	y := h.bounds.Y + historyPadding - h.scroll
+	for _, line := range h.lines {
+		// only what's visible, hands pile up quickly
+		if y+historyLineH >= h.bounds.Y && y <= h.bounds.Y+h.bounds.Height {
+			color := historyColors[min(line.level, len(historyColors)-1)]
+			x := h.bounds.X + historyPadding + float32(line.level*historyIndent)
+			rl.DrawText(line.text, int32(x), int32(y), historyFont, color)
+		}
+		y += historyLineH
+	}
+	rl.EndScissorMode()
+}
+
+func (h *HistoryComponent) GetBounds() rl.Rectangle {
+	return h.bounds
+}
+
+func (h *HistoryComponent) Rebuild(old RGComponent) {
+	if old == nil {
+		return
+	}
+
+	if oldH, ok := old.(*HistoryComponent); ok {
+		h.scroll = oldH.scroll
+		h.follow = oldH.follow
+	}
+}
@@ -2,20 +2,36 @@ package window
 
 import (
 	"fmt"
+	"strings"
+
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
+type potLine struct {
+	name     string
+	amount   int
+	eligible []string
+}
+
 type PotDisplayComponent struct {
 	bounds   rl.Rectangle
 	Pot      int
 	RoundBet int
 	MyChips  int
+	// spectators have no chips to show
+	Spectating bool
+	pots       []potLine
 }
 
 func NewPotDisplayComponent(pot, roundBet, chips int) *PotDisplayComponent {
 	return &PotDisplayComponent{Pot: pot, RoundBet: roundBet, MyChips: chips}
 }
 
+// AddPot lists a main or side pot on the left with the players that can win it
+func (p *PotDisplayComponent) AddPot(name string, amount int, eligible []string) {
+	p.pots = append(p.pots, potLine{name: name, amount: amount, eligible: eligible})
+}
+
 func (p *PotDisplayComponent) Calculate(bounds rl.Rectangle) { p.bounds = bounds }
 
 func (p *PotDisplayComponent) Draw(eventChannel chan<- UIEvent) {
@@ -25,6 +41,9 @@ func (p *PotDisplayComponent) Draw(eventChannel chan<- UIEvent) {
 	potText := fmt.Sprintf("Pot: %d", p.Pot)
 	roundText := fmt.Sprintf("Round: %d", p.RoundBet)
 	myChipsText := fmt.Sprintf("MyChips: %d", p.MyChips)
+	if p.Spectating {
+		myChipsText = "Spectating"
+	}
 
 	// Center vertically
 	totalH := float32(24 + 16 + 16 + 5)
@@ -38,6 +57,13 @@ func (p *PotDisplayComponent) Draw(eventChannel chan<- UIEvent) {
 
 	chipsW := rl.MeasureText(myChipsText, 16)
 	rl.DrawText(myChipsText, int32(p.bounds.X+(p.bounds.Width-float32(chipsW))/2), int32(y+50), 16, rl.White)
+
+	lineY := p.bounds.Y + 8
+	for _, pot := range p.pots {
+		text := fmt.Sprintf("%s: %d (%s)", pot.name, pot.amount, strings.Join(pot.eligible, ", "))
+		rl.DrawText(text, int32(p.bounds.X+10), int32(lineY), 14, rl.White)
+		lineY += 18
+	}
 }
 
 func (p *PotDisplayComponent) GetBounds() rl.Rectangle { return p.bounds }
@@ -0,0 +1,66 @@
+package window
+
+import (
+	"math"
+
+	rg "github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// SliderComponent picks a whole number between Min and Max. With the focus
+// the arrow keys move it by one.
+type SliderComponent struct {
+	bounds     rl.Rectangle
+	ID         string
+	Value      *int // Pointer to the model value
+	Min        int
+	Max        int
+	max_height float32
+}
+
+func NewSliderComponent(id string, value *int, minValue, maxValue int, max_h float32) *SliderComponent {
+	return &SliderComponent{ID: id, Value: value, Min: minValue, Max: maxValue, max_height: max_h}
+}
+
+func (s *SliderComponent) Calculate(bounds rl.Rectangle) {
+	// keep the bar thin and centered, it looks off stretched over a whole row
+	if bounds.Height > s.max_height {
+		bounds.Y += (bounds.Height - s.max_height) / 2
+		bounds.Height = s.max_height
+	}
+	s.bounds = bounds
+}
+
+func (s *SliderComponent) Draw(eventChannel chan<- UIEvent) {
+	Focus.register(s.ID, false)
+
+	if rl.IsMouseButtonPressed(rl.MouseLeftButton) && rl.CheckCollisionPointRec(rl.GetMousePosition(), s.bounds) {
+		Focus.Set(s.ID)
+	}
+
+	old := min(max(*s.Value, s.Min), s.Max)
+	value := int(math.Round(float64(rg.Slider(s.bounds, "", "", float32(old), float32(s.Min), float32(s.Max)))))
+
+	if Focus.IsFocused(s.ID) {
+		drawFocusRing(s.bounds)
+		if rl.IsKeyPressed(rl.KeyRight) || rl.IsKeyPressedRepeat(rl.KeyRight) {
+			value = min(value+1, s.Max)
+		}
+		if rl.IsKeyPressed(rl.KeyLeft) || rl.IsKeyPressedRepeat(rl.KeyLeft) {
+			value = max(value-1, s.Min)
+		}
+	}
+
+	if value != old {
+		*s.Value = value
+		eventChannel <- UIEvent{SourceID: s.ID, Type: EventValueChange}
+	}
+}
+
+func (s *SliderComponent) GetBounds() rl.Rectangle {
+	return s.bounds
+}
+
+func (s *SliderComponent) Rebuild(old RGComponent) {
+	/* noop, the value lives in the model */
+}
@@ -0,0 +1,165 @@
+package window
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	tableSeatW   = 0.16 // of the layout, per seat box
+	tableSeatH   = 0.26
+	tableMarkerR = 13
+)
+
+// TableLayout puts the seats of a room around an oval table. The local
+// player sits at the bottom and the others follow clockwise by seat index,
+// the way the action goes. The middle is left free for the board and pot.
+type TableLayout struct {
+	bounds rl.Rectangle
+	oval   rl.Rectangle
+	center rl.Rectangle
+
+	seatCount int
+	localSeat int // -1 when spectating, the bottom then stays free for local
+
+	seats   map[int]RGComponent
+	markers map[int][]string
+	local   RGComponent
+
+	slotBounds map[int]rl.Rectangle // by seat index, -1 is the local slot
+}
+
+func NewTableLayout(seatCount, localSeat int) *TableLayout {
+	return &TableLayout{
+		seatCount:  max(seatCount, localSeat+1),
+		localSeat:  localSeat,
+		seats:      make(map[int]RGComponent),
+		markers:    make(map[int][]string),
+		slotBounds: make(map[int]rl.Rectangle),
+	}
+}
+
+// SetSeat places a player, the local seat is taken by SetLocal instead
+func (t *TableLayout) SetSeat(idx int, player RGComponent) { t.seats[idx] = player }
+
+// SetLocal is what is shown at the bottom, the own hand or the spectator note
+func (t *TableLayout) SetLocal(local RGComponent) { t.local = local }
+
+// AddMarker puts a button like "D", "SB" or "BB" on the felt in front of a seat
+func (t *TableLayout) AddMarker(idx int, marker string) {
+	t.markers[idx] = append(t.markers[idx], marker)
+}
+
+// CenterBounds is the free middle of the table, valid after Calculate
+func (t *TableLayout) CenterBounds() rl.Rectangle { return t.center }
+
+// slot is the position around the table, 0 is the bottom
+func (t *TableLayout) slot(idx int) (slot, slots int) {
+	if t.localSeat >= 0 {
+		return (idx - t.localSeat + t.seatCount) % t.seatCount, t.seatCount
+	}
+	return idx + 1, t.seatCount + 1
+}
+
+func (t *TableLayout) Calculate(bounds rl.Rectangle) {
+	t.bounds = bounds
+
+	seatW := bounds.Width * tableSeatW
+	seatH := bounds.Height * tableSeatH
+
+	// the seats sit on the rim, half on the felt
+	t.oval = rl.Rectangle{X: bounds.X + seatW/2, Y: bounds.Y + seatH/2, Width: bounds.Width - seatW, Height: bounds.Height - seatH}
+	cx, cy := t.oval.X+t.oval.Width/2, t.oval.Y+t.oval.Height/2
+	t.center = rl.Rectangle{X: cx - t.oval.Width*0.25, Y: cy - t.oval.Height*0.22, Width: t.oval.Width * 0.5, Height: t.oval.Height * 0.44}
+
+	place := func(key, slot, slots int, w float32) rl.Rectangle {
+		angle := math.Pi/2 + float64(slot)*2*math.Pi/float64(slots)
+		x := cx + t.oval.Width/2*float32(math.Cos(angle))
+		y := cy + t.oval.Height/2*float32(math.Sin(angle))
+
+		r := rl.Rectangle{X: x - w/2, Y: y - seatH/2, Width: w, Height: seatH}
+		r.X = min(max(r.X, bounds.X), bounds.X+bounds.Width-r.Width)
+		r.Y = min(max(r.Y, bounds.Y), bounds.Y+bounds.Height-r.Height)
+		t.slotBounds[key] = r
+		return r
+	}
+
+	clear(t.slotBounds)
+	for idx := range t.seatCount {
+		if idx == t.localSeat {
+			continue
+		}
+
+		slot, slots := t.slot(idx)
+		r := place(idx, slot, slots, seatW)
+		if seat, ok := t.seats[idx]; ok {
+			seat.Calculate(r)
+		}
+	}
+
+	// the own hand gets more room than the others
+	_, slots := t.slot(0)
+	r := place(-1, 0, slots, seatW*1.6)
+	if t.local != nil {
+		t.local.Calculate(r)
+	}
+}
+
+func (t *TableLayout) Draw(eventChannel chan<- UIEvent) {
+	cx, cy := int32(t.oval.X+t.oval.Width/2), int32(t.oval.Y+t.oval.Height/2)
+	rx, ry := t.oval.Width/2, t.oval.Height/2
+
+	rl.DrawEllipse(cx, cy, rx+10, ry+10, rl.NewColor(90, 55, 25, 255))
+	rl.DrawEllipse(cx, cy, rx, ry, rl.NewColor(30, 110, 60, 255))
+	rl.DrawEllipseLines(cx, cy, rx-14, ry-14, rl.NewColor(60, 140, 80, 255))
+
+	for idx := range t.seatCount {
+		if seat, ok := t.seats[idx]; ok && idx != t.localSeat {
+			seat.Draw(eventChannel)
+		}
+	}
+	if t.local != nil {
+		t.local.Draw(eventChannel)
+	}
+
+	for idx, markers := range t.markers {
+		key := idx
+		if idx == t.localSeat {
+			key = -1
+		}
+		t.drawMarkers(t.slotBounds[key], markers)
+	}
+}
+
+// drawMarkers puts the buttons between the seat and the middle of the table
+func (t *TableLayout) drawMarkers(seat rl.Rectangle, markers []string) {
+	cx, cy := t.oval.X+t.oval.Width/2, t.oval.Y+t.oval.Height/2
+	sx, sy := seat.X+seat.Width/2, seat.Y+seat.Height/2
+
+	// just inside the seat box, on the side facing the middle
+	x := cx + (sx-cx)*0.62
+	y := cy + (sy-cy)*0.62
+	x -= float32(len(markers)-1) * tableMarkerR
+
+	for _, marker := range markers {
+		fill, text := rl.RayWhite, rl.Black
+		switch marker {
+		case "SB":
+			fill, text = rl.SkyBlue, rl.Black
+		case "BB":
+			fill, text = rl.Orange, rl.Black
+		}
+
+		rl.DrawCircleV(rl.Vector2{X: x, Y: y}, tableMarkerR, fill)
+		rl.DrawCircleLinesV(rl.Vector2{X: x, Y: y}, tableMarkerR, rl.DarkGray)
+		textW := rl.MeasureText(marker, 12)
+		rl.DrawText(marker, int32(x)-textW/2, int32(y)-6, 12, text)
+		x += tableMarkerR * 2
+	}
+}
+
+func (t *TableLayout) GetBounds() rl.Rectangle { return t.bounds }
+
+func (t *TableLayout) Rebuild(old RGComponent) { /* noop, rebuilt every frame from the table */
+}
@@ -10,7 +10,6 @@ type TextBoxComponent struct {
 	ID       string
 	Text     *string // Pointer to the model string
 	maxChars int
-	editMode bool
 }
 
 func NewTextBoxComponent(id string, text *string, maxChars int) *TextBoxComponent {
@@ -26,11 +25,18 @@ func (t *TextBoxComponent) Calculate(bounds rl.Rectangle) {
 }
 
 func (t *TextBoxComponent) Draw(eventChannel chan<- UIEvent) {
+	Focus.register(t.ID, true)
+
+	// edit mode is the focus, clicks move it like Tab does
 	if rl.IsMouseButtonPressed(rl.MouseLeftButton) {
-		t.editMode = rl.CheckCollisionPointRec(rl.GetMousePosition(), t.bounds)
+		if rl.CheckCollisionPointRec(rl.GetMousePosition(), t.bounds) {
+			Focus.Set(t.ID)
+		} else {
+			Focus.Release(t.ID)
+		}
 	}
 
-	changed := rg.TextBox(t.bounds, t.Text, t.maxChars, t.editMode)
+	changed := rg.TextBox(t.bounds, t.Text, t.maxChars, Focus.IsFocused(t.ID))
 
 	if changed {
 		eventChannel <- UIEvent{SourceID: t.ID, Type: EventValueChange}
@@ -42,11 +48,5 @@ func (t *TextBoxComponent) GetBounds() rl.Rectangle {
 }
 
 func (t *TextBoxComponent) Rebuild(old RGComponent) {
-	if old == nil {
-		return
-	}
-
-	if oldTBC, ok := old.(*TextBoxComponent); ok {
-		t.editMode = oldTBC.editMode
-	}
+	/* noop, the edit mode lives in Focus */
 }